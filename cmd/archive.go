@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/session"
+	"github.com/emaland/ccs/internal/state"
+)
+
+var (
+	archiveForce      bool
+	archiveJSON       bool
+	restoreContinue   bool
+	restoreNoTerminal bool
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <name>",
+	Short: "Put a session away without losing its work",
+	Long: `Remove a session's worktree, branch and window, keeping everything needed
+to bring it back with 'ccs restore':
+
+  - the branch is saved as refs/ccs/archive/<name>
+  - uncommitted and untracked files are saved as a checkpoint
+  - the base, parent and Claude transcripts are recorded in global state
+
+Claude must not be running in the session; stop it first with 'ccs pause'.
+Use this instead of 'ccs finish --delete' when you might want the work back.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := sessMgr.Archive(args[0], archiveForce)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Archived %s\n", sess.Name)
+		fmt.Printf("Restore it with 'ccs restore %s'\n", sess.Name)
+		return nil
+	},
+}
+
+var archiveLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List archived sessions in this repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		archived := sessMgr.Archived()
+
+		if archiveJSON {
+			if archived == nil {
+				archived = []state.SessionState{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(archived)
+		}
+
+		if len(archived) == 0 {
+			fmt.Println("No archived sessions.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SESSION\tBASE\tARCHIVED\tREF")
+		for _, s := range archived {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.BaseBranch, s.LastAccess.Local().Format("2006-01-02 15:04"), s.ArchiveRef)
+		}
+		return w.Flush()
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <name> [-- claude-args...]",
+	Short: "Bring back an archived session",
+	Long: `Recreate an archived session's branch and worktree where they were, with
+its uncommitted changes, and open a terminal window for it.
+
+With --continue, Claude is started in the window and continues the
+session's last conversation:
+  ccs restore auth --continue`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := session.RestoreOptions{
+			Continue:   restoreContinue,
+			NoTerminal: restoreNoTerminal,
+			ClaudeArgs: args[1:],
+		}
+
+		sess, err := sessMgr.Restore(args[0], opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Restored %s\n", sess.Name)
+		fmt.Printf("  Branch: %s (from %s)\n", sess.Branch, sess.BaseBranch)
+		fmt.Printf("  Path:   %s\n", sess.Path)
+		if restoreContinue && (restoreNoTerminal || term.Name() == "none") {
+			fmt.Printf("\nContinue the conversation with:\n  cd %s && claude --continue\n", sess.Path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	archiveCmd.Flags().BoolVar(&archiveForce, "force", false, "Move sessions stacked on this one onto its base")
+	archiveLsCmd.Flags().BoolVar(&archiveJSON, "json", false, "Output as JSON")
+	archiveCmd.AddCommand(archiveLsCmd)
+	restoreCmd.Flags().BoolVar(&restoreContinue, "continue", false, "Start Claude and continue the last conversation")
+	restoreCmd.Flags().BoolVar(&restoreNoTerminal, "no-terminal", false, "Don't create terminal window/tab")
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/session"
+)
+
+var checkpointJSON bool
+
+var checkpointCmd = &cobra.Command{
+	Use:   "checkpoint [name] [label]",
+	Short: "Save the state of a session's worktree",
+	Long: `Snapshot a session's worktree, including uncommitted and untracked files,
+so it can be restored with 'ccs rollback'. The branch and working tree are
+left untouched; checkpoints are kept under refs/ccs/checkpoints/<session>/.
+
+Defaults to the current session. A single argument is a session name if
+one exists, and otherwise a label for the current session:
+
+  ccs checkpoint                      # Current session, numbered
+  ccs checkpoint "tests pass"         # Current session, labelled
+  ccs checkpoint auth "before refactor"
+
+Set [checkpoint] auto = true to checkpoint every time Claude stops (needs
+'ccs hooks install'). Only the most recent [checkpoint] keep automatic
+checkpoints are kept, and unchanged states are skipped.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sess *session.Session
+		var label string
+		var err error
+
+		switch len(args) {
+		case 2:
+			sess, err = sessMgr.Get(args[0])
+			label = args[1]
+		case 1:
+			if sess, err = sessMgr.Get(args[0]); err != nil {
+				sess, err = sessMgr.GetCurrent()
+				label = args[0]
+			}
+		default:
+			sess, err = sessMgr.GetCurrent()
+		}
+		if err != nil {
+			return err
+		}
+
+		cp, err := sessMgr.Checkpoint(sess, label, false)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Checkpoint %d of %s saved (%.7s)\n", cp.Number, sess.Name, cp.Commit)
+		return nil
+	},
+}
+
+var checkpointLsCmd = &cobra.Command{
+	Use:   "ls [name]",
+	Short: "List a session's checkpoints",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sess *session.Session
+		var err error
+
+		if len(args) > 0 {
+			sess, err = sessMgr.Get(args[0])
+		} else {
+			sess, err = sessMgr.GetCurrent()
+		}
+		if err != nil {
+			return err
+		}
+
+		checkpoints, err := sessMgr.Checkpoints(sess)
+		if err != nil {
+			return err
+		}
+
+		if checkpointJSON {
+			if checkpoints == nil {
+				checkpoints = []*session.Checkpoint{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(checkpoints)
+		}
+
+		if len(checkpoints) == 0 {
+			fmt.Printf("No checkpoints for %s.\n", sess.Name)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tTIME\tCOMMIT\tLABEL")
+		for _, cp := range checkpoints {
+			label := cp.Label
+			if cp.Auto {
+				label = "(auto)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%.7s\t%s\n", cp.Number, cp.Time.Local().Format("2006-01-02 15:04"), cp.Commit, label)
+		}
+		return w.Flush()
+	},
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [name] <checkpoint>",
+	Short: "Restore a session's worktree to a checkpoint",
+	Long: `Restore a session's worktree to a checkpoint, given by number or label.
+
+The branch is reset to the commit it was on at the checkpoint, uncommitted
+and untracked files are restored, and files that weren't there are removed
+(ignored files are kept). The current state is checkpointed first, so the
+rollback can be undone. Claude must not be running in the session; stop it
+first with 'ccs pause'.
+
+  ccs rollback "tests pass"
+  ccs rollback auth 3`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sess *session.Session
+		var err error
+
+		name := args[len(args)-1]
+		if len(args) == 2 {
+			sess, err = sessMgr.Get(args[0])
+		} else {
+			sess, err = sessMgr.GetCurrent()
+		}
+		if err != nil {
+			return err
+		}
+
+		cp, err := sessMgr.FindCheckpoint(sess, name)
+		if err != nil {
+			return err
+		}
+
+		saved, err := sessMgr.Rollback(sess, cp)
+		if saved != nil {
+			fmt.Printf("Saved the previous state as checkpoint %d\n", saved.Number)
+		}
+		if err != nil {
+			return fmt.Errorf("could not roll back: %w", err)
+		}
+		fmt.Printf("Rolled %s back to checkpoint %d (%s)\n", sess.Name, cp.Number, cp.Name())
+		return nil
+	},
+}
+
+func init() {
+	checkpointLsCmd.Flags().BoolVar(&checkpointJSON, "json", false, "Output as JSON")
+	checkpointCmd.AddCommand(checkpointLsCmd)
+	rootCmd.AddCommand(checkpointCmd)
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/session"
+)
+
+var (
+	compareNoTests bool
+	compareJSON    bool
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <name> <name> [name...]",
+	Short: "Compare sessions side by side",
+	Long: `Compare sessions that attempt the same task, e.g. ones made with 'ccs fork',
+to pick the best one.
+
+For each session this shows its commits, its diffstat against its base,
+Claude's token usage and cost, and the results of the test commands in
+[compare] tests in .ccs.toml, run in each worktree:
+
+  [compare]
+  tests = ["go test ./...", "go vet ./..."]
+
+Sessions are ranked by tests passed, then by size of change, then by cost.
+The branches of every pair of sessions are also diffed against each other;
+use 'git diff ccs/<a> ccs/<b>' to see the changes.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		results, pairs, err := sessMgr.Compare(args, !compareNoTests)
+		if err != nil {
+			return err
+		}
+
+		if compareJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Sessions []*session.Comparison `json:"sessions"`
+				Pairs    []session.PairDiff    `json:"pairs"`
+			}{results, pairs})
+		}
+
+		ranTests := !compareNoTests && len(cfg.Compare.Tests) > 0
+		uncommitted := false
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		header := "#\tSESSION\tCOMMITS\tFILES\tLINES"
+		if ranTests {
+			header += "\tTESTS"
+		}
+		fmt.Fprintln(w, header+"\tTOKENS\tCOST")
+		for i, c := range results {
+			name := c.Name
+			if c.Uncommitted {
+				name += " *"
+				uncommitted = true
+			}
+			row := fmt.Sprintf("%d\t%s\t%d\t%d\t+%d -%d", i+1, name, c.Commits, c.FilesChanged, c.Insertions, c.Deletions)
+			if ranTests {
+				row += fmt.Sprintf("\t%d/%d", c.Passed(), len(c.Tests))
+			}
+			row += fmt.Sprintf("\t%s in / %s out\t$%.2f", formatTokens(c.TokensIn), formatTokens(c.TokensOut), c.Cost)
+			fmt.Fprintln(w, row)
+		}
+		w.Flush()
+		if uncommitted {
+			fmt.Println("\n* has uncommitted changes, which tests see but diffs don't include")
+		}
+
+		if ranTests {
+			fmt.Println("\nTests:")
+			w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for i, command := range cfg.Compare.Tests {
+				row := "  " + command
+				for _, c := range results {
+					t := c.Tests[i]
+					result := "pass"
+					if !t.Passed {
+						result = "FAIL"
+					}
+					row += fmt.Sprintf("\t%s %s (%.1fs)", c.Name, result, t.Seconds)
+				}
+				fmt.Fprintln(w, row)
+			}
+			w.Flush()
+
+			for _, c := range results {
+				for _, t := range c.Tests {
+					if !t.Passed && t.Output != "" {
+						fmt.Printf("\n%s: %s failed:\n%s\n", c.Name, t.Command, indent(t.Output, "  "))
+					}
+				}
+			}
+		}
+
+		fmt.Println("\nBetween sessions:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, p := range pairs {
+			fmt.Fprintf(w, "  %s..%s\t%d files\t+%d -%d\n", p.A, p.B, p.FilesChanged, p.Insertions, p.Deletions)
+		}
+		return w.Flush()
+	},
+}
+
+// indent prefixes every line of s
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+func init() {
+	compareCmd.Flags().BoolVar(&compareNoTests, "no-tests", false, "Don't run the configured test commands")
+	compareCmd.Flags().BoolVar(&compareJSON, "json", false, "Output as JSON")
+	rootCmd.AddCommand(compareCmd)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/disk"
+	"github.com/emaland/ccs/internal/git"
+)
+
+var (
+	duRefresh bool
+	duJSON    bool
+)
+
+var duCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Show disk usage of sessions across all repositories",
+	Long: `Show how much disk each session's worktree takes, largest first, split into
+tracked files, untracked files and ignored files such as node_modules and
+build output. Git's object store is shared between worktrees and not
+counted.
+
+Sizes are cached and measured again when the worktree, a directory up to
+two levels below it or its git index changes. Use --refresh to measure
+everything now.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		type sessionUsage struct {
+			Repo    string     `json:"repo"`
+			Session string     `json:"session"`
+			Path    string     `json:"path"`
+			Usage   disk.Usage `json:"usage"`
+			Total   int64      `json:"total"`
+		}
+
+		cache := disk.OpenDefaultCache()
+		var usages []sessionUsage
+		for _, s := range stateMgr.GetAllSessions() {
+			if s.Archived {
+				continue
+			}
+			if _, err := os.Stat(s.WorkTree); err != nil {
+				continue
+			}
+			u, err := worktreeUsage(cache, s.WorkTree, duRefresh)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not measure %s: %v\n", s.Name, err)
+				continue
+			}
+			usages = append(usages, sessionUsage{Repo: s.RepoName, Session: s.Name, Path: s.WorkTree, Usage: u, Total: u.Total()})
+		}
+		saveDiskCache(cache)
+
+		sort.SliceStable(usages, func(i, j int) bool {
+			return usages[i].Total > usages[j].Total
+		})
+
+		if duJSON {
+			if usages == nil {
+				usages = []sessionUsage{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(usages)
+		}
+
+		if len(usages) == 0 {
+			fmt.Println("No sessions found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REPO\tSESSION\tTRACKED\tUNTRACKED\tIGNORED\tTOTAL")
+		var total int64
+		roots := map[string]int64{}
+		counts := map[string]int{}
+		for _, u := range usages {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", u.Repo, u.Session,
+				formatSize(u.Usage.Tracked), formatSize(u.Usage.Untracked), formatSize(u.Usage.Ignored), formatSize(u.Total))
+			total += u.Total
+			root := filepath.Dir(u.Path)
+			roots[root] += u.Total
+			counts[root]++
+		}
+		w.Flush()
+
+		// Worktrees are grouped by the directory they live in, e.g.
+		// ~/.ccs/<repo> or <repo>/.worktrees
+		var dirs []string
+		for dir := range roots {
+			dirs = append(dirs, dir)
+		}
+		sort.Slice(dirs, func(i, j int) bool { return roots[dirs[i]] > roots[dirs[j]] })
+
+		fmt.Println("\nWorktree roots:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, dir := range dirs {
+			fmt.Fprintf(w, "  %s\t%s\t(%d session(s))\n", shortenPath(dir), formatSize(roots[dir]), counts[dir])
+		}
+		w.Flush()
+		fmt.Printf("\nTotal: %s in %d worktree(s)\n", formatSize(total), len(usages))
+		return nil
+	},
+}
+
+func saveDiskCache(cache *disk.Cache) {
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save disk usage cache: %v\n", err)
+	}
+}
+
+// worktreeUsage returns the disk usage of a worktree of any repository
+func worktreeUsage(cache *disk.Cache, worktree string, refresh bool) (disk.Usage, error) {
+	g, err := git.NewExecGit(worktree)
+	if err != nil {
+		return disk.Usage{}, err
+	}
+	return cache.Usage(g, worktree, refresh)
+}
+
+func init() {
+	duCmd.Flags().BoolVar(&duRefresh, "refresh", false, "Measure every worktree instead of using cached sizes")
+	duCmd.Flags().BoolVar(&duJSON, "json", false, "Output as JSON")
+	rootCmd.AddCommand(duCmd)
+}
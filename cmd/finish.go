@@ -14,23 +14,31 @@ import (
 var (
 	finishSquash bool
 	finishMerge  bool
+	finishRebase bool
+	finishFFOnly bool
 	finishPR     bool
 	finishDelete bool
 	finishForce  bool
+	finishDryRun bool
 )
 
 var finishCmd = &cobra.Command{
 	Use:   "finish <name>",
 	Short: "Finish a session",
-	Long: `Finish a session by merging, creating a PR, or deleting.
+	Long: `Finish a session by merging, rebasing, creating a PR, or deleting.
 
-Without flags, shows an interactive menu.`,
+Without flags, shows an interactive menu. Use --dry-run to check whether
+the session would merge cleanly before touching anything.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
+		if finishDryRun {
+			return previewFinish(name)
+		}
+
 		// Check if any action was specified
-		hasAction := finishSquash || finishMerge || finishPR || finishDelete
+		hasAction := finishSquash || finishMerge || finishRebase || finishFFOnly || finishPR || finishDelete
 
 		if !hasAction {
 			// Interactive mode
@@ -40,6 +48,8 @@ Without flags, shows an interactive menu.`,
 		opts := session.FinishOptions{
 			Squash: finishSquash,
 			Merge:  finishMerge,
+			Rebase: finishRebase,
+			FFOnly: finishFFOnly,
 			PR:     finishPR,
 			Delete: finishDelete,
 			Force:  finishForce,
@@ -49,6 +59,38 @@ Without flags, shows an interactive menu.`,
 	},
 }
 
+func previewFinish(name string) error {
+	preview, err := sessMgr.PreviewFinish(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Session %s: %d commits, %d files changed relative to %s\n",
+		name, preview.Commits, preview.FilesChanged, preview.Base)
+
+	if preview.CanFastForward {
+		fmt.Printf("  Fast-forward: possible\n")
+	} else {
+		fmt.Printf("  Fast-forward: not possible (%s has moved on)\n", preview.Base)
+	}
+
+	if len(preview.Conflicts) == 0 {
+		fmt.Println("  Merge:        clean")
+	} else {
+		fmt.Printf("  Merge:        %d conflicting file(s)\n", len(preview.Conflicts))
+		for _, f := range preview.Conflicts {
+			fmt.Printf("    %s\n", f)
+		}
+	}
+
+	if preview.BaseDirty {
+		fmt.Printf("\n%s is checked out in %s with uncommitted changes; finishing will be refused.\n",
+			preview.Base, preview.BaseWorktree)
+	}
+
+	return nil
+}
+
 func interactiveFinish(name string) error {
 	sess, err := sessMgr.Get(name)
 	if err != nil {
@@ -56,10 +98,21 @@ func interactiveFinish(name string) error {
 	}
 
 	status, _ := sessMgr.GetStatus(sess)
+	preview, _ := sessMgr.PreviewFinish(name)
+
+	base := sess.BaseBranch
 
-	fmt.Printf("Session %s has %d files changed.\n\n", name, status.FilesChanged)
-	fmt.Printf("[s] Squash and merge to %s\n", cfg.DefaultBase)
-	fmt.Printf("[m] Merge to %s (keep commits)\n", cfg.DefaultBase)
+	fmt.Printf("Session %s has %d files changed.\n", name, status.FilesChanged)
+	if preview != nil && len(preview.Conflicts) > 0 {
+		fmt.Printf("Merging into %s would conflict in: %s\n", base, strings.Join(preview.Conflicts, ", "))
+	}
+	fmt.Println()
+	fmt.Printf("[s] Squash and merge to %s\n", base)
+	fmt.Printf("[m] Merge to %s (keep commits)\n", base)
+	fmt.Printf("[r] Rebase onto %s and fast-forward\n", base)
+	if preview != nil && preview.CanFastForward {
+		fmt.Printf("[f] Fast-forward %s\n", base)
+	}
 	fmt.Println("[p] Push branch for PR")
 	fmt.Println("[d] Delete without merging")
 	fmt.Println("[c] Cancel")
@@ -76,6 +129,10 @@ func interactiveFinish(name string) error {
 		opts.Squash = true
 	case "m":
 		opts.Merge = true
+	case "r":
+		opts.Rebase = true
+	case "f":
+		opts.FFOnly = true
 	case "p":
 		opts.PR = true
 	case "d":
@@ -93,7 +150,11 @@ func interactiveFinish(name string) error {
 func init() {
 	finishCmd.Flags().BoolVar(&finishSquash, "squash", false, "Squash all commits and merge to base")
 	finishCmd.Flags().BoolVar(&finishMerge, "merge", false, "Merge to base (keep commits)")
+	finishCmd.Flags().BoolVar(&finishRebase, "rebase", false, "Rebase onto base, then fast-forward base")
+	finishCmd.Flags().BoolVar(&finishFFOnly, "ff-only", false, "Fast-forward base, failing if it has moved on")
 	finishCmd.Flags().BoolVar(&finishPR, "pr", false, "Push branch for PR, don't merge locally")
 	finishCmd.Flags().BoolVar(&finishDelete, "delete", false, "Delete without merging")
-	finishCmd.Flags().BoolVar(&finishForce, "force", false, "Skip confirmation and hooks")
+	finishCmd.Flags().BoolVar(&finishForce, "force", false, "Skip confirmation and the pre_finish hook")
+	finishCmd.Flags().BoolVar(&finishDryRun, "dry-run", false, "Show whether the merge would conflict without changing anything")
+	finishCmd.MarkFlagsMutuallyExclusive("squash", "merge", "rebase", "ff-only", "pr", "delete")
 }
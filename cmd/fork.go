@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/session"
+)
+
+var (
+	forkCount        int
+	forkConversation bool
+	forkNoClaude     bool
+	forkNoTerminal   bool
+)
+
+var forkCmd = &cobra.Command{
+	Use:   "fork <name> <new-name> [-- claude-args...]",
+	Short: "Fork a session into parallel variants",
+	Long: `Create sessions that start where an existing session is now: from its
+HEAD, with its uncommitted changes (including untracked files) carried over
+as uncommitted changes. Each fork gets its own terminal window.
+
+With --count N, N forks are created, named <new-name>-1 to <new-name>-N, to
+try the same task several ways. With --conversation, Claude's conversation
+is copied into each fork and continued from the same point.
+
+Forks share the source session's base, so any of them can be finished on
+its own. Compare them with 'ccs diff' and 'ccs log'.
+
+  ccs fork auth auth-try --count 3 --conversation`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if forkCount < 1 {
+			return fmt.Errorf("--count must be at least 1")
+		}
+
+		opts := session.ForkOptions{
+			Count:        forkCount,
+			Conversation: forkConversation,
+			NoClaude:     forkNoClaude,
+			NoTerminal:   forkNoTerminal,
+			ClaudeArgs:   args[2:],
+		}
+
+		forks, err := sessMgr.Fork(args[0], args[1], opts)
+		for _, s := range forks {
+			fmt.Printf("Created session %s\n", s.Name)
+			fmt.Printf("  Branch: %s (from %s)\n", s.Branch, s.BaseBranch)
+			fmt.Printf("  Path:   %s\n", s.Path)
+		}
+		return err
+	},
+}
+
+func init() {
+	forkCmd.Flags().IntVarP(&forkCount, "count", "n", 1, "Number of forks to create")
+	forkCmd.Flags().BoolVar(&forkConversation, "conversation", false, "Copy Claude's conversation into each fork and continue it")
+	forkCmd.Flags().BoolVar(&forkNoClaude, "no-claude", false, "Don't start Claude in the forks")
+	forkCmd.Flags().BoolVar(&forkNoTerminal, "no-terminal", false, "Don't create terminal windows/tabs")
+	rootCmd.AddCommand(forkCmd)
+}
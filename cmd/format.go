@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emaland/ccs/internal/claude"
+)
+
+// formatTokens formats a token count compactly, e.g. "12.3k"
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// formatUsage summarizes Claude token usage and cost on one line
+func formatUsage(info *claude.Info) string {
+	s := fmt.Sprintf("%s in / %s out", formatTokens(info.TokensIn), formatTokens(info.TokensOut))
+	if cached := info.CacheReadTokens + info.CacheWriteTokens; cached > 0 {
+		s += fmt.Sprintf(" (%s cached)", formatTokens(cached))
+	}
+	return s + fmt.Sprintf(", ~$%.2f", info.Cost)
+}
+
+// formatSize formats a byte count compactly, e.g. "1.2G"
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// shortenPath replaces the home directory at the start of path with ~
+func shortenPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && len(rel) < len(path) && !strings.HasPrefix(rel, "..") {
+			return "~/" + rel
+		}
+	}
+	return path
+}
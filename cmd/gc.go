@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/session"
+)
+
+var (
+	gcDryRun bool
+	gcJSON   bool
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Archive or delete stale sessions",
+	Long: `Apply the retention policy in [gc] to this repository's sessions:
+
+  [gc]
+  archive_after_days = 14       # archive sessions idle this long
+  delete_merged_after_days = 3  # delete merged sessions idle this long
+  auto = true                   # also run daily from new, switch, finish...
+
+A session is idle when nobody has switched to, resumed or checked the status
+of it, committed to it, or had Claude work in it. It is merged when its
+branch has commits and is contained in its base. Merged sessions with
+uncommitted changes are archived rather than deleted.
+
+Sessions Claude is running in, the current session and sessions others are
+stacked on are never touched. Archived sessions can be brought back with
+'ccs restore'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cfg.GC.Enabled() {
+			return fmt.Errorf("no retention policy configured\n\nSet archive_after_days or delete_merged_after_days in [gc] in .ccs.toml")
+		}
+
+		actions, err := sessMgr.PlanGC(time.Now())
+		if err != nil {
+			return err
+		}
+		if !gcDryRun {
+			sessMgr.GC(actions)
+		}
+
+		if gcJSON {
+			if actions == nil {
+				actions = []*session.GCAction{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(actions)
+		}
+
+		if len(actions) == 0 {
+			fmt.Println("No stale sessions.")
+			return nil
+		}
+		printGCReport(os.Stdout, actions, gcDryRun)
+		return nil
+	},
+}
+
+// printGCReport lists what the retention policy did, or would do, to each
+// session and the disk space reclaimed
+func printGCReport(out io.Writer, actions []*session.GCAction, dryRun bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tSESSION\tREASON\tSIZE")
+	var reclaimed int64
+	var count int
+	for _, a := range actions {
+		action := a.Action
+		if a.Error != "" {
+			action += " (failed)"
+		} else {
+			reclaimed += a.Size
+			count++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", action, a.Name, a.Reason, formatSize(a.Size))
+	}
+	w.Flush()
+
+	for _, a := range actions {
+		if a.Error != "" {
+			fmt.Fprintf(out, "\nCould not %s %s: %s\n", a.Action, a.Name, a.Error)
+		}
+	}
+
+	verb := "Reclaimed"
+	if dryRun {
+		verb = "Would reclaim"
+	}
+	fmt.Fprintf(out, "\n%s %s from %d session(s)\n", verb, formatSize(reclaimed), count)
+}
+
+// autoGC enforces the retention policy if [gc] auto is set and it hasn't
+// run in this repository today, reporting to stderr so command output is
+// unaffected. Sessions named on the command line are left for the command.
+func autoGC(args []string) {
+	if !sessMgr.AutoGCDue(time.Now()) {
+		return
+	}
+	planned, err := sessMgr.PlanGC(time.Now())
+	if err != nil {
+		return
+	}
+	named := map[string]bool{}
+	for _, arg := range args {
+		named[arg] = true
+	}
+	var actions []*session.GCAction
+	for _, a := range planned {
+		if !named[a.Name] {
+			actions = append(actions, a)
+		}
+	}
+	if len(actions) == 0 {
+		return
+	}
+	sessMgr.GC(actions)
+	fmt.Fprintln(os.Stderr, "ccs gc: applying the retention policy in [gc]")
+	printGCReport(os.Stderr, actions, false)
+	fmt.Fprintln(os.Stderr)
+}
+
+func init() {
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Show what would be archived or deleted")
+	gcCmd.Flags().BoolVar(&gcJSON, "json", false, "Output as JSON")
+	rootCmd.AddCommand(gcCmd)
+}
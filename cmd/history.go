@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/state"
+)
+
+var (
+	historyRepo    string
+	historySession string
+	historySince   string
+	historyJSON    bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the history of session events",
+	Long: `Show when sessions were created, switched to, paused, resumed, synced,
+finished and deleted, across all repositories.
+
+  ccs history --repo                # Only the current repository
+  ccs history --repo=myapp          # Only the repository named myapp
+  ccs history --session auth        # Only sessions named auth
+  ccs history --since 7d            # The last week (also 24h, 2025-06-01)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var filter state.EventFilter
+
+		switch {
+		case historyRepo == ".":
+			if gitRepo == nil {
+				return fmt.Errorf("not in a git repository")
+			}
+			filter.RepoPath = gitRepo.RepoRoot()
+		case filepath.IsAbs(historyRepo):
+			filter.RepoPath = historyRepo
+		default:
+			filter.RepoName = historyRepo
+		}
+		filter.Session = historySession
+
+		if historySince != "" {
+			since, err := parseSince(historySince, time.Now())
+			if err != nil {
+				return err
+			}
+			filter.Since = since
+		}
+
+		events, err := stateMgr.Events(filter)
+		if err != nil {
+			return err
+		}
+
+		if historyJSON {
+			if events == nil {
+				events = []state.Event{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(events)
+		}
+
+		if len(events) == 0 {
+			fmt.Println("No events found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tREPO\tSESSION\tEVENT\tDETAIL")
+		for _, ev := range events {
+			detail := ev.Detail
+			if ev.Commit != "" {
+				detail = strings.TrimSpace(fmt.Sprintf("%s %.7s", detail, ev.Commit))
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				ev.Time.Local().Format("2006-01-02 15:04"), ev.RepoName, ev.Session, ev.Type, detail)
+		}
+		return w.Flush()
+	},
+}
+
+// parseSince parses a --since value: a duration back from now such as
+// "90m", "24h" or "7d", or a date such as "2025-06-01"
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use e.g. 24h, 7d or 2025-06-01)", s)
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyRepo, "repo", "", "Only events for a repository (current if no name is given)")
+	historyCmd.Flags().Lookup("repo").NoOptDefVal = "."
+	historyCmd.Flags().StringVar(&historySession, "session", "", "Only events for sessions with this name")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only events since a time (e.g. 24h, 7d, 2025-06-01)")
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Output as JSON")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -1,44 +1,53 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/config"
+	"github.com/emaland/ccs/internal/git"
+	"github.com/emaland/ccs/internal/session"
+	"github.com/emaland/ccs/internal/terminal"
 )
 
 var hooksCmd = &cobra.Command{
 	Use:   "hooks",
 	Short: "Manage Claude Code hooks",
-	Long:  `Manage CCS integration with Claude Code hooks.`,
+	Long: `Manage CCS integration with Claude Code hooks.
+
+The installed hooks report Claude's state (running, waiting for permission,
+idle) to CCS so that 'ccs ls' can show which sessions need attention.
+
+Hooks are installed in your Claude Code user settings, so Claude runs them in
+every session worktree without changing any repository. Outside sessions
+they do nothing.`,
 }
 
 var hooksInstallCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install CCS hooks into Claude Code",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		hookDir := filepath.Join(gitRepo.RepoRoot(), ".claude", "hooks")
-		if err := os.MkdirAll(hookDir, 0755); err != nil {
-			return fmt.Errorf("could not create hooks directory: %w", err)
-		}
-
-		// Create stop hook
-		stopHook := `#!/bin/bash
-# CCS hook: stop
-CCS_SESSION=$(ccs _current-session 2>/dev/null)
-if [[ -n "$CCS_SESSION" ]]; then
-    # Session stopped notification could be added here
-    true
-fi
-`
-		stopPath := filepath.Join(hookDir, "stop")
-		if err := os.WriteFile(stopPath, []byte(stopHook), 0755); err != nil {
-			return fmt.Errorf("could not write stop hook: %w", err)
-		}
-
-		fmt.Println("Installed CCS hooks:")
-		fmt.Printf("  %s\n", stopPath)
+		settingsPath, err := claude.UserSettingsPath()
+		if err != nil {
+			return err
+		}
+		if err := claude.InstallHooks(settingsPath); err != nil {
+			return fmt.Errorf("could not install hooks: %w", err)
+		}
+		removeProjectHooks()
+
+		fmt.Printf("Installed CCS hooks in %s:\n", settingsPath)
+		for _, event := range claude.HookEvents {
+			fmt.Printf("  %s -> %s %s\n", event, claude.HookCommandPrefix, event)
+		}
 		return nil
 	},
 }
@@ -47,16 +56,14 @@ var hooksUninstallCmd = &cobra.Command{
 	Use:   "uninstall",
 	Short: "Remove CCS hooks from Claude Code",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		hookDir := filepath.Join(gitRepo.RepoRoot(), ".claude", "hooks")
-
-		// Remove our hooks
-		hooks := []string{"stop"}
-		for _, hook := range hooks {
-			path := filepath.Join(hookDir, hook)
-			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-				fmt.Printf("Warning: could not remove %s: %v\n", path, err)
-			}
+		settingsPath, err := claude.UserSettingsPath()
+		if err != nil {
+			return err
+		}
+		if err := claude.UninstallHooks(settingsPath); err != nil {
+			return fmt.Errorf("could not uninstall hooks: %w", err)
 		}
+		removeProjectHooks()
 
 		fmt.Println("Uninstalled CCS hooks")
 		return nil
@@ -67,31 +74,137 @@ var hooksStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show CCS hooks status",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		hookDir := filepath.Join(gitRepo.RepoRoot(), ".claude", "hooks")
-
-		hooks := []string{"stop"}
-		installed := false
-
-		for _, hook := range hooks {
-			path := filepath.Join(hookDir, hook)
-			if _, err := os.Stat(path); err == nil {
-				if !installed {
-					fmt.Println("Installed hooks:")
-					installed = true
-				}
-				fmt.Printf("  %s\n", path)
-			}
+		settingsPath, err := claude.UserSettingsPath()
+		if err != nil {
+			return err
+		}
+		events, err := claude.InstalledHooks(settingsPath)
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", settingsPath, err)
 		}
 
-		if !installed {
+		if len(events) == 0 {
 			fmt.Println("No CCS hooks installed.")
 			fmt.Println("Run 'ccs hooks install' to install them.")
+			return nil
+		}
+
+		fmt.Printf("Installed hooks (%s):\n", settingsPath)
+		for _, event := range events {
+			fmt.Printf("  %s\n", event)
+		}
+		if len(events) < len(claude.HookEvents) {
+			fmt.Println("\nSome hooks are missing. Run 'ccs hooks install' to repair.")
 		}
 
 		return nil
 	},
 }
 
+// removeProjectHooks removes the hooks older versions installed in the
+// repository's project settings and .claude/hooks, which only the main
+// checkout saw
+func removeProjectHooks() {
+	if gitRepo == nil {
+		return
+	}
+	root := gitRepo.RepoRoot()
+
+	settingsPath := claude.SettingsPath(root)
+	if events, err := claude.InstalledHooks(settingsPath); err == nil && len(events) > 0 {
+		if err := claude.UninstallHooks(settingsPath); err != nil {
+			fmt.Printf("Warning: could not remove CCS hooks from %s: %v\n", settingsPath, err)
+		} else {
+			fmt.Printf("Removed CCS hooks installed by an older version from %s\n", settingsPath)
+		}
+	}
+
+	legacy := filepath.Join(root, ".claude", "hooks", "stop")
+	if err := os.Remove(legacy); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: could not remove %s: %v\n", legacy, err)
+	}
+}
+
+// hookInput is the subset of the JSON Claude Code sends to hooks on stdin
+type hookInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	Message        string `json:"message"`
+}
+
+var hookCmd = &cobra.Command{
+	Use:    "_hook <event>",
+	Hidden: true,
+	Short:  "Record Claude state from a Claude Code hook",
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		event := args[0]
+
+		// Never fail: a hook error would interrupt Claude
+		state, ok := claude.StateForEvent(event)
+		if !ok {
+			return nil
+		}
+
+		var input hookInput
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+			if data, err := io.ReadAll(os.Stdin); err == nil {
+				json.Unmarshal(data, &input)
+			}
+		}
+
+		dir := input.Cwd
+		if dir == "" {
+			dir = "."
+		}
+		worktree, err := git.FindRepoRoot(dir)
+		if err != nil {
+			return nil
+		}
+
+		// Hooks are installed for every project, so Claude running outside
+		// a session is ignored
+		c, err := config.Load()
+		if err != nil {
+			return nil
+		}
+		g, err := git.NewExecGit(worktree)
+		if err != nil {
+			return nil
+		}
+		branch, err := g.BranchCurrent()
+		if err != nil || !strings.HasPrefix(branch, c.BranchPrefix) {
+			return nil
+		}
+
+		claude.WriteHookState(worktree, claude.HookState{
+			State:          state,
+			Event:          event,
+			Message:        input.Message,
+			SessionID:      input.SessionID,
+			TranscriptPath: input.TranscriptPath,
+			UpdatedAt:      time.Now(),
+		})
+
+		if event == claude.EventStop && c.Checkpoint.Auto {
+			autoCheckpoint(c, g, worktree, branch)
+		}
+		return nil
+	},
+}
+
+// autoCheckpoint checkpoints the session in worktree when Claude stops.
+// Errors are ignored so Claude is never held up.
+func autoCheckpoint(c *config.Config, g git.Git, worktree, branch string) {
+	mgr := session.NewManager(c, g, &terminal.NoopTerminal{}, nil)
+	mgr.Checkpoint(&session.Session{
+		Name:   strings.TrimPrefix(branch, c.BranchPrefix),
+		Path:   worktree,
+		Branch: branch,
+	}, "", true)
+}
+
 func init() {
 	hooksCmd.AddCommand(hooksInstallCmd)
 	hooksCmd.AddCommand(hooksUninstallCmd)
@@ -3,128 +3,125 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/emaland/ccs/internal/state"
+	"github.com/emaland/ccs/internal/git"
+	"github.com/emaland/ccs/internal/session"
+	"github.com/emaland/ccs/internal/terminal"
 )
 
+var importDryRun bool
+
 var importCmd = &cobra.Command{
 	Use:   "import [path...]",
 	Short: "Import existing worktrees into CCS state",
-	Long: `Scan directories for git worktrees and import them into CCS global state.
+	Long: `Scan repositories for session worktrees (branches with the configured
+prefix) that global state does not know about, and add them.
+
+Paths may be repositories or glob patterns matching repositories:
+  ccs import ~/projects/*
 
-By default, scans ~/scratch/git/*/ for repositories and imports any
-worktrees with ccs/ prefix branches.
+Without paths, the patterns in [import] roots in ~/.config/ccs/config.toml
+are scanned, or the current repository if none are configured.
 
-You can specify paths to scan:
-  ccs import ~/projects/*/`,
+The base of each session is read from the branch config when ccs recorded
+it, and otherwise detected from its upstream or the branch it forked from
+most recently. The creation time is recovered from the worktree.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		stateMgr, err := state.NewManager()
+		patterns := args
+		if len(patterns) == 0 {
+			patterns = cfg.Import.Roots
+		}
+		if len(patterns) == 0 {
+			if gitRepo == nil {
+				return fmt.Errorf("no paths given\n\nPass repositories to scan, or set [import] roots in ~/.config/ccs/config.toml")
+			}
+			patterns = []string{gitRepo.RepoRoot()}
+		}
+
+		repos, err := findRepos(patterns)
 		if err != nil {
-			return fmt.Errorf("could not initialize state manager: %w", err)
+			return err
 		}
 
-		// Default to ~/scratch/git/*/
-		paths := args
-		if len(paths) == 0 {
-			home, _ := os.UserHomeDir()
-			defaultPath := filepath.Join(home, "scratch", "git", "*")
-			matches, _ := filepath.Glob(defaultPath)
-			paths = matches
+		if term == nil {
+			term = terminal.Detect(cfg)
 		}
 
 		var imported int
-		for _, path := range paths {
-			info, err := os.Stat(path)
-			if err != nil || !info.IsDir() {
-				continue
-			}
-
-			// Check if it's a git repo
-			gitDir := filepath.Join(path, ".git")
-			if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-				continue
+		for _, repo := range repos {
+			g, err := git.NewExecGit(repo)
+			if err != nil {
+				return err
 			}
+			mgr := session.NewManager(cfg, g, term, stateMgr)
 
-			// Get worktrees for this repo
-			out, err := exec.Command("git", "-C", path, "worktree", "list", "--porcelain").Output()
+			found, err := mgr.FindImportable()
 			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", repo, err)
 				continue
 			}
-
-			worktrees := parseWorktreeList(string(out))
-			repoName := filepath.Base(path)
-
-			for _, wt := range worktrees {
-				// Only import worktrees with ccs/ prefix branches
-				if !strings.HasPrefix(wt.branch, "ccs/") {
-					continue
+			for _, imp := range found {
+				base := imp.BaseBranch
+				if imp.Detected {
+					base += ", detected"
 				}
-
-				// Skip if already in state
-				if stateMgr.GetSession(wt.path) != nil {
+				if importDryRun {
+					fmt.Printf("Would import: %s/%s (%s, from %s)\n", g.RepoName(), imp.Name, imp.Path, base)
+					imported++
 					continue
 				}
-
-				name := strings.TrimPrefix(wt.branch, "ccs/")
-				stateMgr.AddSession(state.SessionState{
-					Name:       name,
-					RepoPath:   path,
-					RepoName:   repoName,
-					WorkTree:   wt.path,
-					Branch:     wt.branch,
-					BaseBranch: "main", // Assume main, we can't easily determine original base
-					CreatedAt:  time.Now(),
-					LastAccess: time.Now(),
-				})
+				if err := mgr.Import(imp); err != nil {
+					return fmt.Errorf("could not import %s: %w", imp.Path, err)
+				}
+				fmt.Printf("Imported: %s/%s (%s, from %s)\n", g.RepoName(), imp.Name, imp.Path, base)
 				imported++
-				fmt.Printf("Imported: %s/%s (%s)\n", repoName, name, wt.path)
 			}
 		}
 
-		if imported == 0 {
+		switch {
+		case imported == 0:
 			fmt.Println("No new sessions found to import.")
-		} else {
+		case importDryRun:
+			fmt.Printf("\nWould import %d session(s).\n", imported)
+		default:
 			fmt.Printf("\nImported %d session(s).\n", imported)
 		}
-
 		return nil
 	},
 }
 
-type worktreeInfo struct {
-	path   string
-	branch string
-}
-
-func parseWorktreeList(output string) []worktreeInfo {
-	var worktrees []worktreeInfo
-	var current worktreeInfo
-
-	for _, line := range strings.Split(output, "\n") {
-		if strings.HasPrefix(line, "worktree ") {
-			if current.path != "" {
-				worktrees = append(worktrees, current)
+// findRepos expands patterns to the repositories they match. Only main
+// working trees count: a linked worktree belongs to the repository it was
+// added from, which is where its sessions are recorded.
+func findRepos(patterns []string) ([]string, error) {
+	var repos []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", pattern, err)
+		}
+		for _, path := range matches {
+			path, err = filepath.Abs(path)
+			if err != nil {
+				return nil, err
+			}
+			if info, err := os.Stat(filepath.Join(path, ".git")); err != nil || !info.IsDir() {
+				continue
+			}
+			if !seen[path] {
+				seen[path] = true
+				repos = append(repos, path)
 			}
-			current = worktreeInfo{path: strings.TrimPrefix(line, "worktree ")}
-		} else if strings.HasPrefix(line, "branch ") {
-			branch := strings.TrimPrefix(line, "branch refs/heads/")
-			current.branch = branch
 		}
 	}
-	if current.path != "" {
-		worktrees = append(worktrees, current)
-	}
-
-	return worktrees
+	return repos, nil
 }
 
-// Not exposed as a command - use internally when needed
-// func init() {
-// 	rootCmd.AddCommand(importCmd)
-// }
+func init() {
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without changing anything")
+	rootCmd.AddCommand(importCmd)
+}
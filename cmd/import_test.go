@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindRepos(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet", filepath.Join(dir, "app")},
+		{"init", "--quiet", filepath.Join(dir, "site")},
+		{"-C", filepath.Join(dir, "app"), "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial"},
+		{"-C", filepath.Join(dir, "app"), "worktree", "add", "--quiet", "-b", "ccs/feature", filepath.Join(dir, "app-feature")},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The linked worktree and plain directory are skipped, and app, matched
+	// twice, is listed once
+	got, err := findRepos([]string{filepath.Join(dir, "*"), filepath.Join(dir, "app")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "app"), filepath.Join(dir, "site")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if _, err := findRepos([]string{"["}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
@@ -47,10 +47,7 @@ Defaults to current session. Supports standard git log flags after --.`,
 		}
 
 		wtGit := gitRepo.InWorktree(sess.Path)
-		mergeBase, err := wtGit.MergeBase(cfg.DefaultBase, "HEAD")
-		if err != nil {
-			mergeBase = cfg.DefaultBase
-		}
+		mergeBase := sessMgr.MergeBase(sess)
 
 		output, err := wtGit.Log(mergeBase, "HEAD", gitArgs...)
 		if err != nil {
@@ -8,6 +8,9 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/session"
 )
 
 var (
@@ -38,17 +41,25 @@ var lsCmd = &cobra.Command{
 		type sessionOutput struct {
 			Name         string `json:"name"`
 			Branch       string `json:"branch"`
+			Base         string `json:"base"`
+			Parent       string `json:"parent,omitempty"`
 			Path         string `json:"path"`
 			FilesChanged int    `json:"files_changed"`
 			CommitsAhead int    `json:"commits_ahead"`
 			ClaudeState  string `json:"claude_state"`
 			TerminalInfo string `json:"terminal_info,omitempty"`
 			IsCurrent    bool   `json:"is_current"`
+
+			Claude *claude.Info `json:"claude,omitempty"`
 		}
 
 		var outputs []sessionOutput
+		var depths []int
 
-		for _, sess := range sessions {
+		// Stacked sessions are listed under the session they build on
+		sessions, stackDepths := session.StackOrder(sessions)
+
+		for i, sess := range sessions {
 			status, _ := sessMgr.GetStatus(sess)
 
 			// Filter for running only
@@ -61,6 +72,8 @@ var lsCmd = &cobra.Command{
 			out := sessionOutput{
 				Name:         sess.Name,
 				Branch:       sess.Branch,
+				Base:         sess.BaseBranch,
+				Parent:       sess.Parent,
 				Path:         sess.Path,
 				FilesChanged: status.FilesChanged,
 				CommitsAhead: status.CommitsAhead,
@@ -68,7 +81,11 @@ var lsCmd = &cobra.Command{
 				TerminalInfo: status.TerminalInfo,
 				IsCurrent:    isCurrent,
 			}
+			if lsVerbose || lsJSON {
+				out.Claude = sessMgr.GetClaudeInfo(sess)
+			}
 			outputs = append(outputs, out)
+			depths = append(depths, stackDepths[i])
 		}
 
 		if lsJSON {
@@ -78,7 +95,7 @@ var lsCmd = &cobra.Command{
 		}
 
 		// Table output
-		for _, out := range outputs {
+		for i, out := range outputs {
 			marker := " "
 			if out.IsCurrent {
 				marker = "*"
@@ -99,9 +116,14 @@ var lsCmd = &cobra.Command{
 				}
 			}
 
+			name := out.Name
+			if depths[i] > 0 {
+				name = strings.Repeat("  ", depths[i]-1) + "└─ " + name
+			}
+
 			line := fmt.Sprintf("%s %-18s %-10s %-15s %s",
 				marker,
-				out.Name,
+				name,
 				filesStr,
 				claudeStr,
 				path,
@@ -112,7 +134,19 @@ var lsCmd = &cobra.Command{
 			}
 
 			if lsVerbose {
-				line += "\n    branch: " + out.Branch
+				line += "\n    branch: " + out.Branch + " (from " + out.Base + ")"
+				if info := out.Claude; info != nil && info.Messages > 0 {
+					line += "\n    claude: " + formatUsage(info)
+					if info.Model != "" {
+						line += ", " + info.Model
+					}
+					if info.LastActive != "" {
+						line += ", active " + info.LastActive
+					}
+					if info.LastPrompt != "" {
+						line += "\n    last prompt: " + claude.TruncateLine(info.LastPrompt, 60)
+					}
+				}
 			}
 
 			fmt.Println(strings.TrimRight(line, " "))
@@ -9,10 +9,11 @@ import (
 )
 
 var (
-	newFrom       string
-	newHere       bool
-	newNoClaude   bool
-	newNoTerminal bool
+	newFrom        string
+	newFromSession string
+	newHere        bool
+	newNoClaude    bool
+	newNoTerminal  bool
 )
 
 var newCmd = &cobra.Command{
@@ -23,6 +24,9 @@ var newCmd = &cobra.Command{
 The session will be created from the current branch (or --from if specified).
 A new terminal window/tab will be opened, and Claude will be started.
 
+With --from-session the new session is stacked on an existing one: it
+branches from that session's branch, and syncs with and finishes into it.
+
 Any arguments after -- are passed to Claude:
   ccs new my-feature -- --dangerously-skip-permissions
   ccs new bugfix -- --continue --model sonnet`,
@@ -37,11 +41,12 @@ Any arguments after -- are passed to Claude:
 		}
 
 		opts := session.CreateOptions{
-			From:       newFrom,
-			Here:       newHere,
-			NoClaude:   newNoClaude,
-			NoTerminal: newNoTerminal,
-			ClaudeArgs: claudeArgs,
+			From:        newFrom,
+			FromSession: newFromSession,
+			Here:        newHere,
+			NoClaude:    newNoClaude,
+			NoTerminal:  newNoTerminal,
+			ClaudeArgs:  claudeArgs,
 		}
 
 		sess, err := sessMgr.Create(name, opts)
@@ -66,7 +71,9 @@ Any arguments after -- are passed to Claude:
 }
 
 func init() {
-	newCmd.Flags().StringVar(&newFrom, "from", "", "Base branch/commit (default: current branch)")
+	newCmd.Flags().StringVar(&newFrom, "from", "", "Base branch (default: current branch)")
+	newCmd.Flags().StringVar(&newFromSession, "from-session", "", "Stack on top of an existing session")
+	newCmd.MarkFlagsMutuallyExclusive("from", "from-session")
 	newCmd.Flags().BoolVar(&newHere, "here", false, "Create worktree in ./.worktrees/<name>")
 	newCmd.Flags().BoolVar(&newNoClaude, "no-claude", false, "Don't start Claude after creation")
 	newCmd.Flags().BoolVar(&newNoTerminal, "no-terminal", false, "Don't create terminal window/tab")
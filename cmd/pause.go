@@ -5,7 +5,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/emaland/ccs/internal/claude"
 	"github.com/emaland/ccs/internal/session"
 )
 
@@ -23,7 +22,7 @@ var pauseCmd = &cobra.Command{
 				return err
 			}
 			for _, sess := range sessions {
-				if err := claude.StopProcess(sess.Path); err != nil {
+				if err := sessMgr.Pause(sess.Name); err != nil {
 					fmt.Printf("Warning: could not stop Claude for %s: %v\n", sess.Name, err)
 				} else {
 					fmt.Printf("Paused %s\n", sess.Name)
@@ -44,8 +43,8 @@ var pauseCmd = &cobra.Command{
 			return err
 		}
 
-		if err := claude.StopProcess(sess.Path); err != nil {
-			return fmt.Errorf("could not stop Claude: %w", err)
+		if err := sessMgr.Pause(sess.Name); err != nil {
+			return err
 		}
 
 		fmt.Printf("Paused %s\n", sess.Name)
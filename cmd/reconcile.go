@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var reconcileDryRun bool
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Find and repair drift between state, git and terminal windows",
+	Long: `Compare global state with this repository's worktrees and branches and the
+terminal's windows, print what is out of sync, and fix it:
+
+  unlinked       worktree on disk that git lost track of     git worktree repair
+  prunable       worktree git knows whose directory is gone  git worktree prune
+  untracked      session worktree missing from state         add to state
+  worktree-gone  state entry whose worktree is gone          remove from state
+  branch-gone    state entry whose branch is gone            remove from state
+  orphan-branch  session branch with no worktree             delete if merged
+  dead-window    window for a session that no longer exists  close window
+
+Orphan branches that are not merged are only reported, since they may hold
+work (e.g. a branch pushed with 'ccs finish --pr').`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		drift, err := sessMgr.FindDrift()
+		if err != nil {
+			return err
+		}
+
+		if len(drift) == 0 {
+			fmt.Println("Everything is in sync.")
+			return nil
+		}
+
+		fmt.Printf("Found %d problem(s):\n", len(drift))
+		for _, d := range drift {
+			fmt.Printf("  %-14s %-18s %s\n", d.Kind, d.Session, d.Problem)
+			fmt.Printf("  %-14s %-18s -> %s\n", "", "", d.Action)
+		}
+
+		if reconcileDryRun {
+			fmt.Println("\nDry run, nothing changed.")
+			return nil
+		}
+
+		fmt.Println()
+		fixed, failed, manual := 0, 0, 0
+		for _, d := range drift {
+			if !d.Fixable {
+				manual++
+				continue
+			}
+			if err := sessMgr.FixDrift(d); err != nil {
+				fmt.Printf("%s: could not %s: %v\n", d.Session, d.Action, err)
+				failed++
+				continue
+			}
+			fixed++
+		}
+
+		fmt.Printf("Fixed %d problem(s)", fixed)
+		if manual > 0 {
+			fmt.Printf(", %d need(s) your attention", manual)
+		}
+		fmt.Println(".")
+		if failed > 0 {
+			return fmt.Errorf("%d fix(es) failed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().BoolVar(&reconcileDryRun, "dry-run", false, "Print the plan without changing anything")
+	rootCmd.AddCommand(reconcileCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <name> <new-name>",
+	Short: "Rename a session",
+	Long: `Rename a session's branch, worktree directory and terminal window.
+
+Claude's transcripts move with the worktree, so 'ccs resume' continues the
+conversation. Sessions stacked on the renamed one follow its new branch.
+Claude must not be running in the session.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		old, err := sessMgr.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		sess, err := sessMgr.Rename(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Renamed %s to %s\n", args[0], sess.Name)
+		fmt.Printf("  Branch: %s\n", sess.Branch)
+		fmt.Printf("  Worktree: %s\n", sess.Path)
+
+		// A shell in the old directory is left somewhere that no longer
+		// exists. Getwd already follows the move, so ask the shell.
+		if sess.Path != old.Path && isWithinDir(old.Path, os.Getenv("PWD")) {
+			fmt.Printf("\ncd %s\n", sess.Path)
+		}
+		return nil
+	},
+}
+
+// isWithinDir reports whether path is dir or lies beneath it
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
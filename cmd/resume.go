@@ -2,11 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/emaland/ccs/internal/claude"
 	"github.com/emaland/ccs/internal/session"
 )
 
@@ -44,25 +42,8 @@ Any additional arguments after -- are passed to Claude:
 			return err
 		}
 
-		// Check if already running
-		state := claude.GetState(sess.Path)
-		if state == claude.StateRunning || state == claude.StateWaiting {
-			return fmt.Errorf("Claude is already running for %s", sess.Name)
-		}
-
-		// Build claude command with --continue and any additional args
-		claudeCmd := "claude --continue"
-		if len(claudeArgs) > 0 {
-			claudeCmd += " " + strings.Join(claudeArgs, " ")
-		}
-
-		// Create terminal window with Claude running in login shell
-		if term.Name() != "none" {
-			if err := term.CreateWindow(sess.Name, sess.Path, claudeCmd); err != nil {
-				return fmt.Errorf("could not create terminal window: %w", err)
-			}
-		} else {
-			return fmt.Errorf("no terminal available - switch to session directory and run claude manually")
+		if err := sessMgr.Resume(sess.Name, claudeArgs); err != nil {
+			return err
 		}
 
 		if len(claudeArgs) > 0 {
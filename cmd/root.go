@@ -17,7 +17,7 @@ var (
 	cfg      *config.Config
 	gitRepo  git.Git
 	term     terminal.Terminal
-	stateMgr *state.Manager
+	stateMgr state.Store
 	sessMgr  *session.Manager
 	rootCmd  = &cobra.Command{
 		Use:   "ccs",
@@ -30,7 +30,8 @@ while sharing the git object store.`,
 		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Skip initialization for commands that don't need it
-			if cmd.Name() == "help" || cmd.Name() == "version" {
+			// _hook runs on every Claude tool call and must stay fast
+			if cmd.Name() == "help" || cmd.Name() == "version" || cmd.Name() == "_hook" {
 				return nil
 			}
 
@@ -41,7 +42,7 @@ while sharing the git object store.`,
 			}
 
 			// Initialize state manager (global, not repo-specific)
-			stateMgr, err = state.NewManager()
+			stateMgr, err = state.Open(cfg.State.Backend)
 			if err != nil {
 				return fmt.Errorf("failed to initialize state: %w", err)
 			}
@@ -50,7 +51,11 @@ while sharing the git object store.`,
 			repoRoot, err := git.FindRepoRoot(".")
 			if err != nil {
 				// Some commands might not need a repo
-				if cmd.Name() == "shell-init" || cmd.Name() == "sessions" || cmd.Name() == "cleanup" {
+				if cmd.Parent() == hooksCmd {
+					return nil
+				}
+				switch cmd.Name() {
+				case "shell-init", "sessions", "cleanup", "transcript", "migrate", "history", "import", "du":
 					return nil
 				}
 				return fmt.Errorf("not in a git repository")
@@ -64,6 +69,14 @@ while sharing the git object store.`,
 			term = terminal.Detect(cfg)
 			sessMgr = session.NewManager(cfg, gitRepo, term, stateMgr)
 
+			// Only commands that change sessions enforce the retention policy.
+			// Read-only ones run from prompts, completions and scripts.
+			switch cmd.Name() {
+			case "new", "switch", "finish", "pause", "resume", "fork", "rename", "sync",
+				"archive", "restore", "checkpoint", "rollback", "cleanup":
+				autoGC(args)
+			}
+
 			return nil
 		},
 	}
@@ -88,6 +101,7 @@ func init() {
 	rootCmd.AddCommand(currentSessionCmd)
 	rootCmd.AddCommand(previousSessionCmd)
 	rootCmd.AddCommand(sessionPathCmd)
+	rootCmd.AddCommand(hookCmd)
 }
 
 func printError(err error) {
@@ -4,16 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/disk"
+	"github.com/emaland/ccs/internal/state"
 )
 
 var (
 	sessionsJSON bool
+	sessionsSize bool
 )
 
 var sessionsCmd = &cobra.Command{
@@ -22,7 +24,8 @@ var sessionsCmd = &cobra.Command{
 	Long: `List all CCS sessions globally, across all repositories.
 
 This shows sessions tracked in the global state, regardless of which
-repository you're currently in.`,
+repository you're currently in. With --size, each worktree's disk usage is
+shown too; see 'ccs du' for a breakdown.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if stateMgr == nil {
 			return fmt.Errorf("state manager not initialized")
@@ -36,43 +39,86 @@ repository you're currently in.`,
 			return nil
 		}
 
+		procs, _ := claude.ScanProcesses()
+
+		var cache *disk.Cache
+		if sessionsSize {
+			cache = disk.OpenDefaultCache()
+			defer saveDiskCache(cache)
+		}
+		// size returns a worktree's disk usage, or nil if it isn't measured
+		size := func(s state.SessionState) *disk.Usage {
+			if cache == nil || s.Archived {
+				return nil
+			}
+			if _, err := os.Stat(s.WorkTree); err != nil {
+				return nil
+			}
+			u, err := worktreeUsage(cache, s.WorkTree, false)
+			if err != nil {
+				return nil
+			}
+			return &u
+		}
+
 		if sessionsJSON {
+			type sessionOutput struct {
+				state.SessionState
+				Claude *claude.Info `json:"claude,omitempty"`
+				Size   *disk.Usage  `json:"size,omitempty"`
+			}
+
+			outputs := make([]sessionOutput, len(sessions))
+			for i, s := range sessions {
+				outputs[i].SessionState = s
+				if _, err := os.Stat(s.WorkTree); err == nil {
+					outputs[i].Claude = procs.Info(s.WorkTree, cfg.Claude.Pricing)
+				}
+				outputs[i].Size = size(s)
+			}
+
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetIndent("", "  ")
-			return enc.Encode(sessions)
+			return enc.Encode(outputs)
 		}
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintf(w, "REPO\tSESSION\tBRANCH\tPATH\tSTATUS\n")
+		header := "REPO\tSESSION\tBRANCH\tPATH\tSTATUS"
+		if sessionsSize {
+			header += "\tSIZE"
+		}
+		fmt.Fprintln(w, header)
 
 		for _, s := range sessions {
 			// Check if worktree still exists
 			status := "ok"
-			if _, err := os.Stat(s.WorkTree); os.IsNotExist(err) {
+			if s.Archived {
+				status = "archived"
+			} else if _, err := os.Stat(s.WorkTree); os.IsNotExist(err) {
 				status = "missing"
 			} else {
 				// Check claude state
-				claudeState := claude.GetState(s.WorkTree)
+				claudeState := procs.State(s.WorkTree)
 				if claudeState != claude.StateUnknown {
 					status = string(claudeState)
 				}
 			}
 
-			// Shorten path for display
-			path := s.WorkTree
-			if home, err := os.UserHomeDir(); err == nil {
-				if rel, err := filepath.Rel(home, path); err == nil && len(rel) < len(path) {
-					path = "~/" + rel
-				}
-			}
-
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
 				s.RepoName,
 				s.Name,
 				s.Branch,
-				path,
+				shortenPath(s.WorkTree),
 				status,
 			)
+			if sessionsSize {
+				if u := size(s); u != nil {
+					row += "\t" + formatSize(u.Total())
+				} else {
+					row += "\t-"
+				}
+			}
+			fmt.Fprintln(w, row)
 		}
 
 		return w.Flush()
@@ -81,5 +127,6 @@ repository you're currently in.`,
 
 func init() {
 	sessionsCmd.Flags().BoolVar(&sessionsJSON, "json", false, "Output as JSON")
+	sessionsCmd.Flags().BoolVar(&sessionsSize, "size", false, "Show the disk usage of each worktree")
 	rootCmd.AddCommand(sessionsCmd)
 }
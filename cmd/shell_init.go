@@ -56,12 +56,12 @@ _ccs_completions() {
     local cmd="${COMP_WORDS[1]}"
 
     if [[ ${COMP_CWORD} -eq 1 ]]; then
-        COMPREPLY=($(compgen -W "new ls switch status finish diff log pause resume hooks shell-init" -- "$cur"))
+        COMPREPLY=($(compgen -W "new fork ls switch status finish sync diff log compare pause resume rename transcript checkpoint rollback archive restore gc du history state reconcile import hooks shell-init" -- "$cur"))
         return
     fi
 
     case "$cmd" in
-        switch|status|finish|diff|log|pause|resume)
+        switch|status|finish|sync|diff|log|compare|pause|resume|rename|fork|transcript|checkpoint|rollback|archive)
             local sessions=$(ccs ls --json 2>/dev/null | grep -o '"name":"[^"]*"' | cut -d'"' -f4)
             COMPREPLY=($(compgen -W "$sessions" -- "$cur"))
             ;;
@@ -94,10 +94,25 @@ _ccs() {
         'switch:Switch to a session'
         'status:Show session status'
         'finish:Finish a session'
+        'sync:Update a session with its base branch'
         'diff:Show diff for a session'
         'log:Show log for a session'
+        'compare:Compare sessions side by side'
         'pause:Pause a session'
         'resume:Resume a session'
+        'rename:Rename a session'
+        'fork:Fork a session into parallel variants'
+        'transcript:Export a session conversation'
+        'checkpoint:Save the state of a session'
+        'rollback:Restore a session to a checkpoint'
+        'archive:Put a session away without losing its work'
+        'restore:Bring back an archived session'
+        'gc:Archive or delete stale sessions'
+        'du:Show disk usage of sessions'
+        'history:Show session history'
+        'state:Manage the global session state store'
+        'reconcile:Repair drift between state, git and windows'
+        'import:Import existing worktrees into ccs'
         'hooks:Manage Claude Code hooks'
         'shell-init:Output shell integration'
     )
@@ -108,7 +123,7 @@ _ccs() {
     fi
 
     case "$words[2]" in
-        switch|status|finish|diff|log|pause|resume)
+        switch|status|finish|sync|diff|log|compare|pause|resume|rename|fork|transcript|checkpoint|rollback|archive)
             sessions=(${(f)"$(ccs ls --json 2>/dev/null | grep -o '"name":"[^"]*"' | cut -d'"' -f4)"})
             _describe 'session' sessions
             ;;
@@ -146,14 +161,29 @@ complete -c ccs -n "__fish_use_subcommand" -a "ls" -d "List sessions"
 complete -c ccs -n "__fish_use_subcommand" -a "switch" -d "Switch to a session"
 complete -c ccs -n "__fish_use_subcommand" -a "status" -d "Show session status"
 complete -c ccs -n "__fish_use_subcommand" -a "finish" -d "Finish a session"
+complete -c ccs -n "__fish_use_subcommand" -a "sync" -d "Update a session with its base branch"
 complete -c ccs -n "__fish_use_subcommand" -a "diff" -d "Show diff for a session"
 complete -c ccs -n "__fish_use_subcommand" -a "log" -d "Show log for a session"
+complete -c ccs -n "__fish_use_subcommand" -a "compare" -d "Compare sessions side by side"
 complete -c ccs -n "__fish_use_subcommand" -a "pause" -d "Pause a session"
 complete -c ccs -n "__fish_use_subcommand" -a "resume" -d "Resume a session"
+complete -c ccs -n "__fish_use_subcommand" -a "rename" -d "Rename a session"
+complete -c ccs -n "__fish_use_subcommand" -a "fork" -d "Fork a session into parallel variants"
+complete -c ccs -n "__fish_use_subcommand" -a "transcript" -d "Export a session conversation"
+complete -c ccs -n "__fish_use_subcommand" -a "checkpoint" -d "Save the state of a session"
+complete -c ccs -n "__fish_use_subcommand" -a "rollback" -d "Restore a session to a checkpoint"
+complete -c ccs -n "__fish_use_subcommand" -a "archive" -d "Put a session away without losing its work"
+complete -c ccs -n "__fish_use_subcommand" -a "restore" -d "Bring back an archived session"
+complete -c ccs -n "__fish_use_subcommand" -a "gc" -d "Archive or delete stale sessions"
+complete -c ccs -n "__fish_use_subcommand" -a "du" -d "Show disk usage of sessions"
+complete -c ccs -n "__fish_use_subcommand" -a "history" -d "Show session history"
+complete -c ccs -n "__fish_use_subcommand" -a "state" -d "Manage the global session state store"
+complete -c ccs -n "__fish_use_subcommand" -a "reconcile" -d "Repair drift between state, git and windows"
+complete -c ccs -n "__fish_use_subcommand" -a "import" -d "Import existing worktrees into ccs"
 complete -c ccs -n "__fish_use_subcommand" -a "hooks" -d "Manage Claude Code hooks"
 complete -c ccs -n "__fish_use_subcommand" -a "shell-init" -d "Output shell integration"
 
-complete -c ccs -n "__fish_seen_subcommand_from switch status finish diff log pause resume" -a "(__ccs_sessions)"
+complete -c ccs -n "__fish_seen_subcommand_from switch status finish sync diff log compare pause resume rename fork transcript checkpoint rollback archive" -a "(__ccs_sessions)"
 
 # Prompt integration
 function _ccs_prompt
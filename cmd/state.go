@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/state"
+)
+
+var stateMigrateTo string
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the global session state store",
+}
+
+var stateMigrateCmd = &cobra.Command{
+	Use:   "migrate --to <backend>",
+	Short: "Copy global state to another backend",
+	Long: `Copy all tracked sessions, and any session history, from the current state
+backend to another one ("json" or "sqlite").
+
+The current backend is left untouched. Switch to the new one by setting
+[state] backend in ~/.config/ccs/config.toml.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from := cfg.State.Backend
+		if from == "" {
+			from = state.BackendJSON
+		}
+		if stateMigrateTo == from {
+			return fmt.Errorf("state is already stored in %s", from)
+		}
+
+		dest, err := state.Open(stateMigrateTo)
+		if err != nil {
+			return err
+		}
+		defer dest.Close()
+
+		sessions := stateMgr.GetAllSessions()
+		for _, s := range sessions {
+			if err := dest.AddSession(s); err != nil {
+				return fmt.Errorf("could not copy session %s: %w", s.Name, err)
+			}
+		}
+
+		events, err := stateMgr.Events(state.EventFilter{})
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			if err := dest.RecordEvent(ev); err != nil {
+				return fmt.Errorf("could not copy history: %w", err)
+			}
+		}
+
+		fmt.Printf("Copied %d session(s) and %d event(s) from %s to %s.\n", len(sessions), len(events), from, stateMigrateTo)
+		fmt.Printf("To use it, add to ~/.config/ccs/config.toml:\n\n  [state]\n  backend = %q\n", stateMigrateTo)
+		return nil
+	},
+}
+
+func init() {
+	stateMigrateCmd.Flags().StringVar(&stateMigrateTo, "to", "", "Backend to copy state to (json, sqlite)")
+	stateMigrateCmd.MarkFlagRequired("to")
+	stateCmd.AddCommand(stateMigrateCmd)
+	rootCmd.AddCommand(stateCmd)
+}
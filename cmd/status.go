@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/emaland/ccs/internal/claude"
 	"github.com/emaland/ccs/internal/session"
 )
 
@@ -25,6 +26,7 @@ var statusCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		sessMgr.Touch(sess)
 
 		status, err := sessMgr.GetStatus(sess)
 		if err != nil {
@@ -33,13 +35,16 @@ var statusCmd = &cobra.Command{
 
 		// Get more details
 		wtGit := gitRepo.InWorktree(sess.Path)
-		mergeBase, _ := wtGit.MergeBase(cfg.DefaultBase, "HEAD")
+		mergeBase := sessMgr.MergeBase(sess)
 		commitCount, _ := wtGit.CommitCount(mergeBase, "HEAD")
 
 		fmt.Printf("Session: %s\n", sess.Name)
 		fmt.Printf("Branch:  %s (based on %s, %d commits ahead)\n",
-			sess.Branch, cfg.DefaultBase, commitCount)
+			sess.Branch, sess.BaseBranch, commitCount)
 		fmt.Printf("Path:    %s\n", sess.Path)
+		if base, count := sessMgr.Ports(sess); count > 0 {
+			fmt.Printf("Ports:   %d-%d (CCS_PORT_BASE, in .ccs/env)\n", base, base+count-1)
+		}
 		fmt.Println()
 
 		// Show files changed
@@ -53,7 +58,25 @@ var statusCmd = &cobra.Command{
 		}
 
 		// Show Claude status
-		fmt.Printf("Claude: %s\n", status.ClaudeState)
+		info := sessMgr.GetClaudeInfo(sess)
+		if info.PID != 0 {
+			fmt.Printf("Claude: %s (pid %d)\n", status.ClaudeState, info.PID)
+		} else {
+			fmt.Printf("Claude: %s\n", status.ClaudeState)
+		}
+		if info.Messages > 0 {
+			if info.Model != "" {
+				fmt.Printf("  Model:       %s\n", info.Model)
+			}
+			fmt.Printf("  Usage:       %s\n", formatUsage(info))
+			fmt.Printf("  Messages:    %d\n", info.Messages)
+		}
+		if info.LastActive != "" {
+			fmt.Printf("  Last active: %s\n", info.LastActive)
+		}
+		if info.LastPrompt != "" {
+			fmt.Printf("  Last prompt: %s\n", claude.TruncateLine(info.LastPrompt, 72))
+		}
 
 		return nil
 	},
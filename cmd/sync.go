@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/session"
+)
+
+var (
+	syncAll     bool
+	syncRebase  bool
+	syncMerge   bool
+	syncNoFetch bool
+	syncClaude  bool
+	syncStack   bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [name]",
+	Short: "Update a session with the latest base branch",
+	Long: `Fetch, then rebase a session onto its base branch (or merge the base in).
+
+Defaults to the current session. The strategy defaults to the [sync] strategy
+setting ("rebase" unless configured). If Claude is running in the session it
+is paused first; 'ccs resume' restarts it. Uncommitted changes are stashed
+around the update.
+
+On conflict the update is aborted and the conflicting files are reported.
+With --claude, the conflict is left in place and Claude is started in the
+session's window to resolve it.
+
+Sessions stacked on another session (ccs new --from-session) sync with that
+session's branch. With --stack, the sessions stacked on the given one are
+synced after it, in order, so a change to a parent propagates up the stack.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sessions []*session.Session
+		var err error
+
+		switch {
+		case syncAll:
+			sessions, err = sessMgr.List()
+			sessions, _ = session.StackOrder(sessions)
+		case syncStack:
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			} else {
+				var sess *session.Session
+				if sess, err = sessMgr.GetCurrent(); err != nil {
+					return err
+				}
+				name = sess.Name
+			}
+			sessions, err = sessMgr.StackFrom(name)
+		case len(args) > 0:
+			var sess *session.Session
+			sess, err = sessMgr.Get(args[0])
+			sessions = []*session.Session{sess}
+		default:
+			var sess *session.Session
+			sess, err = sessMgr.GetCurrent()
+			sessions = []*session.Session{sess}
+		}
+		if err != nil {
+			return err
+		}
+
+		if !syncNoFetch {
+			fmt.Println("Fetching...")
+			if err := gitRepo.Fetch(); err != nil {
+				fmt.Printf("Warning: fetch failed: %v\n", err)
+			}
+		}
+
+		opts := session.SyncOptions{ResolveWithClaude: syncClaude}
+		if syncRebase {
+			opts.Strategy = session.SyncRebase
+		} else if syncMerge {
+			opts.Strategy = session.SyncMerge
+		}
+
+		failed := 0
+		notSynced := map[string]bool{}
+		for _, sess := range sessions {
+			// A session can't be synced until the one it's stacked on is
+			if notSynced[sess.Parent] {
+				fmt.Printf("%s: skipped, %s was not synced\n", sess.Name, sess.Parent)
+				notSynced[sess.Name] = true
+				failed++
+				continue
+			}
+			result, err := sessMgr.Sync(sess.Name, opts)
+			if err != nil {
+				fmt.Printf("%s: %v\n", sess.Name, err)
+				notSynced[sess.Name] = true
+				failed++
+				continue
+			}
+			printSyncResult(result)
+			if len(result.Conflicts) > 0 {
+				notSynced[sess.Name] = true
+				if !result.HandedToClaude {
+					failed++
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d session(s) could not be synced", failed)
+		}
+		return nil
+	},
+}
+
+func printSyncResult(result *session.SyncResult) {
+	if result.UpToDate {
+		fmt.Printf("%s: up to date with %s\n", result.Session, result.Onto)
+		return
+	}
+
+	if result.Paused && !result.HandedToClaude {
+		fmt.Printf("%s: paused Claude, run 'ccs resume %s' to restart it\n", result.Session, result.Session)
+	}
+
+	if len(result.Conflicts) == 0 {
+		verb := "rebased onto"
+		if result.Strategy == session.SyncMerge {
+			verb = "merged"
+		}
+		fmt.Printf("%s: %s %s\n", result.Session, verb, result.Onto)
+		return
+	}
+
+	fmt.Printf("%s: %s with %s conflicts in:\n", result.Session, result.Strategy, result.Onto)
+	for _, f := range result.Conflicts {
+		fmt.Printf("    %s\n", f)
+	}
+	if result.HandedToClaude {
+		fmt.Printf("  Claude started in %s to resolve the conflict\n", result.Session)
+	} else {
+		fmt.Printf("  Aborted. Run 'ccs sync %s --claude' to have Claude resolve it\n", result.Session)
+	}
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncAll, "all", false, "Sync all sessions in this repository")
+	syncCmd.Flags().BoolVar(&syncRebase, "rebase", false, "Rebase onto the base branch")
+	syncCmd.Flags().BoolVar(&syncMerge, "merge", false, "Merge the base branch in")
+	syncCmd.Flags().BoolVar(&syncNoFetch, "no-fetch", false, "Don't fetch before syncing")
+	syncCmd.Flags().BoolVar(&syncClaude, "claude", false, "Leave conflicts in place and start Claude to resolve them")
+	syncCmd.Flags().BoolVar(&syncStack, "stack", false, "Also sync the sessions stacked on this one")
+	syncCmd.MarkFlagsMutuallyExclusive("rebase", "merge")
+	syncCmd.MarkFlagsMutuallyExclusive("all", "stack")
+	rootCmd.AddCommand(syncCmd)
+}
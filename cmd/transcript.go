@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emaland/ccs/internal/claude"
+)
+
+var (
+	transcriptFormat string
+	transcriptOutput string
+	transcriptRedact bool
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript [name]",
+	Short: "Export a session's Claude conversation",
+	Long: `Export the Claude conversation that produced a session as Markdown, HTML or JSON.
+
+Defaults to the current session. Sessions from other repositories tracked in
+the global state can be named as <repo>/<name>. Tool calls are collapsed and
+file edits are shown as diffs.
+
+  ccs transcript my-feature > my-feature.md
+  ccs transcript my-feature --format html -o review.html
+  ccs transcript --redact-output --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+
+		sessName, path, err := resolveSessionPath(name)
+		if err != nil {
+			return err
+		}
+
+		files, err := claude.TranscriptFiles(path)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no Claude transcripts found for %s (looked in %s)", sessName, claude.ProjectDir(path))
+		}
+
+		conv, err := claude.LoadConversation(files)
+		if err != nil {
+			return fmt.Errorf("could not read transcript: %w", err)
+		}
+		conv.Session = sessName
+		conv.Path = path
+
+		if transcriptRedact {
+			conv.RedactToolOutput()
+		}
+
+		var w io.Writer = os.Stdout
+		if transcriptOutput != "" {
+			f, err := os.Create(transcriptOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		return conv.Render(w, transcriptFormat)
+	},
+}
+
+// resolveSessionPath finds the name and worktree path of a session, looking
+// first in the current repository and then in the global state so that
+// sessions from other repositories, or whose worktree is gone, still resolve.
+// An empty name means the current session.
+func resolveSessionPath(name string) (string, string, error) {
+	if name == "" {
+		if sessMgr == nil {
+			return "", "", fmt.Errorf("not in a git repository")
+		}
+		sess, err := sessMgr.GetCurrent()
+		if err != nil {
+			return "", "", err
+		}
+		return sess.Name, sess.Path, nil
+	}
+
+	if sessMgr != nil {
+		if sess, err := sessMgr.Get(name); err == nil {
+			return sess.Name, sess.Path, nil
+		}
+	}
+
+	var matches []string
+	var sessName string
+	for _, s := range stateMgr.GetAllSessions() {
+		if s.Name == name || s.RepoName+"/"+s.Name == name {
+			matches = append(matches, s.WorkTree)
+			sessName = s.Name
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", "", fmt.Errorf("session %q not found", name)
+	case 1:
+		return sessName, matches[0], nil
+	default:
+		return "", "", fmt.Errorf("session name %q is ambiguous, use <repo>/<name>:\n  %s", name, strings.Join(matches, "\n  "))
+	}
+}
+
+func init() {
+	transcriptCmd.Flags().StringVarP(&transcriptFormat, "format", "f", claude.FormatMarkdown, "Output format: md, html or json")
+	transcriptCmd.Flags().StringVarP(&transcriptOutput, "output", "o", "", "Write to file instead of stdout")
+	transcriptCmd.Flags().BoolVar(&transcriptRedact, "redact-output", false, "Omit tool output from the export")
+	rootCmd.AddCommand(transcriptCmd)
+}
@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"strconv"
-	"strings"
 	"syscall"
+	"time"
+
+	"github.com/emaland/ccs/internal/config"
 )
 
 // State represents the state of a Claude process
@@ -22,130 +22,101 @@ const (
 
 // Info contains information about a Claude process
 type Info struct {
-	State      State
-	PID        int
-	LastActive string // e.g., "12m ago"
-	TokensIn   int
-	TokensOut  int
+	State            State      `json:"state"`
+	PID              int        `json:"pid,omitempty"`
+	LastActive       string     `json:"last_active,omitempty"` // e.g., "12m ago"
+	LastActivity     *time.Time `json:"last_activity,omitempty"`
+	TokensIn         int        `json:"tokens_in"`
+	TokensOut        int        `json:"tokens_out"`
+	CacheReadTokens  int        `json:"cache_read_tokens"`
+	CacheWriteTokens int        `json:"cache_write_tokens"`
+	Model            string     `json:"model,omitempty"`
+	Cost             float64    `json:"cost"`
+	Messages         int        `json:"messages"`
+	LastPrompt       string     `json:"last_prompt,omitempty"`
 }
 
 // GetState returns the Claude state for a session path
 func GetState(sessionPath string) State {
-	pid, err := GetProcessPID(sessionPath)
-	if err != nil || pid == 0 {
+	procs, err := ScanProcesses()
+	if err != nil {
 		return StateIdle
 	}
-
-	// Check if process is waiting for input (simplified check)
-	// A more accurate check would inspect the process's file descriptors
-	if isWaitingForInput(pid) {
-		return StateWaiting
-	}
-
-	return StateRunning
+	return procs.State(sessionPath)
 }
 
 // GetInfo returns full Claude info for a session path
-func GetInfo(sessionPath string) *Info {
-	info := &Info{
-		State: GetState(sessionPath),
-	}
-
-	pid, err := GetProcessPID(sessionPath)
-	if err == nil {
-		info.PID = pid
-	}
-
-	// TODO: Parse .claude directory for token usage and last prompt
-	// This requires understanding Claude's session storage format
-
-	return info
+func GetInfo(sessionPath string, pricing map[string]config.ModelPrice) *Info {
+	procs, _ := ScanProcesses()
+	return procs.Info(sessionPath, pricing)
 }
 
-// GetProcessPID finds the Claude process running in the given session path
-func GetProcessPID(sessionPath string) (int, error) {
-	// Use ps to find claude processes
-	out, err := exec.Command("ps", "aux").Output()
-	if err != nil {
-		return 0, err
+// Info returns full Claude info for path based on the snapshot, including
+// usage parsed from the session's transcripts
+func (t *ProcessTable) Info(sessionPath string, pricing map[string]config.ModelPrice) *Info {
+	info := &Info{
+		State: t.State(sessionPath),
+		PID:   t.PID(sessionPath),
 	}
 
-	absPath, _ := filepath.Abs(sessionPath)
-
-	for _, line := range strings.Split(string(out), "\n") {
-		if !strings.Contains(line, "claude") {
-			continue
-		}
-		// Skip grep processes
-		if strings.Contains(line, "grep") || strings.Contains(line, "ps aux") {
-			continue
-		}
-
-		// Parse the PID (second field)
-		fields := strings.Fields(line)
-		if len(fields) < 2 {
-			continue
-		}
-
-		pid, err := strconv.Atoi(fields[1])
-		if err != nil {
-			continue
-		}
+	var last time.Time
+	if hs, err := ReadHookState(sessionPath); err == nil {
+		last = hs.UpdatedAt
+	}
 
-		// Check if this process's cwd matches our session path
-		cwd := getProcessCwd(pid)
-		if cwd == absPath {
-			return pid, nil
+	if summary, err := SummarizeTranscript(sessionPath, pricing); err == nil {
+		info.TokensIn = summary.Usage.InputTokens
+		info.TokensOut = summary.Usage.OutputTokens
+		info.CacheReadTokens = summary.Usage.CacheReadTokens
+		info.CacheWriteTokens = summary.Usage.CacheCreationTokens
+		info.Model = summary.Model
+		info.Cost = summary.Cost
+		info.Messages = summary.Messages
+		info.LastPrompt = summary.LastPrompt
+		if summary.LastActivity.After(last) {
+			last = summary.LastActivity
 		}
 	}
 
-	return 0, nil
-}
-
-// getProcessCwd gets the current working directory of a process
-func getProcessCwd(pid int) string {
-	// On macOS, use lsof
-	out, err := exec.Command("lsof", "-a", "-p", strconv.Itoa(pid), "-d", "cwd", "-Fn").Output()
-	if err != nil {
-		return ""
+	if !last.IsZero() {
+		info.LastActivity = &last
+		info.LastActive = formatAgo(last)
 	}
 
-	for _, line := range strings.Split(string(out), "\n") {
-		if strings.HasPrefix(line, "n") {
-			return line[1:]
-		}
-	}
-	return ""
+	return info
 }
 
-// isWaitingForInput checks if a process is waiting for input
-func isWaitingForInput(pid int) bool {
-	// Check process state - if it's in interruptible sleep (S), likely waiting
-	// This is a simplified heuristic
-	out, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+// GetProcessPID finds the Claude process running in the given session path
+func GetProcessPID(sessionPath string) (int, error) {
+	procs, err := ScanProcesses()
 	if err != nil {
-		return false
+		return 0, err
 	}
-	state := strings.TrimSpace(string(out))
-	return strings.HasPrefix(state, "S")
+	return procs.PID(sessionPath), nil
 }
 
-// StopProcess stops a Claude process
+// StopProcess stops the Claude processes running in a session path
 func StopProcess(sessionPath string) error {
-	pid, err := GetProcessPID(sessionPath)
+	procs, err := ScanProcesses()
 	if err != nil {
 		return err
 	}
-	if pid == 0 {
-		return nil // No process to stop
-	}
+	return procs.Stop(sessionPath)
+}
 
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return err
+// formatAgo formats a timestamp relative to now, e.g. "12m ago"
+func formatAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
 	}
-
-	return process.Signal(syscall.SIGTERM)
 }
 
 // StartProcess starts Claude in the given session path with optional args
@@ -0,0 +1,245 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of conversation items
+const (
+	ItemPrompt = "prompt" // Prompt typed by the user
+	ItemReply  = "reply"  // Text written by Claude
+	ItemTool   = "tool"   // Tool call and its output
+	ItemEdit   = "edit"   // Tool call that modified a file
+)
+
+// ConversationItem is one element of a conversation as a reader sees it
+type ConversationItem struct {
+	Kind      string          `json:"kind"`
+	Timestamp time.Time       `json:"timestamp"`
+	Text      string          `json:"text,omitempty"`
+	Tool      string          `json:"tool,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Summary   string          `json:"summary,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	Output    string          `json:"output,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+	File      string          `json:"file,omitempty"`
+	Diff      string          `json:"diff,omitempty"`
+}
+
+// Conversation is the readable form of a session's transcripts
+type Conversation struct {
+	Session string             `json:"session"`
+	Path    string             `json:"path"`
+	Model   string             `json:"model,omitempty"`
+	Started time.Time          `json:"started"`
+	Ended   time.Time          `json:"ended"`
+	Items   []ConversationItem `json:"items"`
+}
+
+// LoadConversation reads transcript files, oldest first, into a
+// Conversation. Entries repeated by resumed conversations, meta entries and
+// subagent sidechains are skipped; tool results are attached to their calls.
+func LoadConversation(files []string) (*Conversation, error) {
+	conv := &Conversation{}
+	seen := map[string]bool{}
+	tools := map[string]int{} // tool_use ID -> index in Items
+
+	for _, path := range files {
+		err := ReadTranscript(path, func(e Entry) error {
+			if e.Message == nil || e.IsMeta || e.IsSidechain {
+				return nil
+			}
+			if e.UUID != "" {
+				if seen[e.UUID] {
+					return nil
+				}
+				seen[e.UUID] = true
+			}
+
+			if !e.Timestamp.IsZero() {
+				if conv.Started.IsZero() || e.Timestamp.Before(conv.Started) {
+					conv.Started = e.Timestamp
+				}
+				if e.Timestamp.After(conv.Ended) {
+					conv.Ended = e.Timestamp
+				}
+			}
+
+			switch e.Type {
+			case "user":
+				conv.addUser(e, tools)
+			case "assistant":
+				if e.Message.Model != "" && e.Message.Model != "<synthetic>" {
+					conv.Model = e.Message.Model
+				}
+				conv.addAssistant(e, tools)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return conv, nil
+}
+
+func (c *Conversation) addUser(e Entry, tools map[string]int) {
+	if e.Message.IsPrompt() {
+		c.Items = append(c.Items, ConversationItem{
+			Kind:      ItemPrompt,
+			Timestamp: e.Timestamp,
+			Text:      strings.TrimSpace(e.Message.Text()),
+		})
+		return
+	}
+
+	for _, b := range e.Message.Content {
+		if b.Type != "tool_result" {
+			continue
+		}
+		i, ok := tools[b.ToolUseID]
+		if !ok {
+			continue
+		}
+		c.Items[i].Output = toolResultText(b.Content)
+		c.Items[i].IsError = b.IsError
+	}
+}
+
+func (c *Conversation) addAssistant(e Entry, tools map[string]int) {
+	for _, b := range e.Message.Content {
+		switch b.Type {
+		case "text":
+			if strings.TrimSpace(b.Text) == "" {
+				continue
+			}
+			c.Items = append(c.Items, ConversationItem{
+				Kind:      ItemReply,
+				Timestamp: e.Timestamp,
+				Text:      strings.TrimSpace(b.Text),
+			})
+
+		case "tool_use":
+			item := ConversationItem{
+				Kind:      ItemTool,
+				Timestamp: e.Timestamp,
+				Tool:      b.Name,
+				ToolUseID: b.ID,
+				Summary:   toolSummary(b.Input),
+				Input:     b.Input,
+			}
+			if file, diff, ok := editDiff(b.Name, b.Input); ok {
+				item.Kind = ItemEdit
+				item.File = file
+				item.Diff = diff
+			}
+			tools[b.ID] = len(c.Items)
+			c.Items = append(c.Items, item)
+		}
+	}
+}
+
+// RedactToolOutput removes tool output, which may contain file contents or
+// secrets, from the conversation
+func (c *Conversation) RedactToolOutput() {
+	for i := range c.Items {
+		if c.Items[i].Output != "" {
+			c.Items[i].Output = "[redacted]"
+		}
+	}
+}
+
+// toolResultText flattens tool_result content, which is either a string or
+// a list of content blocks
+func toolResultText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	var blocks []ContentBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			parts = append(parts, b.Text)
+		case "image":
+			parts = append(parts, "[image]")
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toolSummary picks the most descriptive argument of a tool call
+func toolSummary(input json.RawMessage) string {
+	var args map[string]interface{}
+	if json.Unmarshal(input, &args) != nil {
+		return ""
+	}
+	for _, key := range []string{"command", "file_path", "notebook_path", "path", "pattern", "url", "query", "description"} {
+		if v, ok := args[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// editDiff renders the change made by a file-editing tool call as a
+// unified-style diff
+func editDiff(tool string, input json.RawMessage) (file, diff string, ok bool) {
+	type edit struct {
+		OldString string `json:"old_string"`
+		NewString string `json:"new_string"`
+	}
+	var args struct {
+		FilePath string `json:"file_path"`
+		Content  string `json:"content"`
+		Edits    []edit `json:"edits"`
+		edit
+	}
+
+	switch tool {
+	case "Edit", "MultiEdit", "Write":
+	default:
+		return "", "", false
+	}
+	if json.Unmarshal(input, &args) != nil || args.FilePath == "" {
+		return "", "", false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", args.FilePath, args.FilePath)
+
+	switch tool {
+	case "Write":
+		writeDiffLines(&b, "+", args.Content)
+	case "Edit":
+		args.Edits = []edit{args.edit}
+		fallthrough
+	case "MultiEdit":
+		for _, e := range args.Edits {
+			b.WriteString("@@\n")
+			writeDiffLines(&b, "-", e.OldString)
+			writeDiffLines(&b, "+", e.NewString)
+		}
+	}
+
+	return args.FilePath, strings.TrimSuffix(b.String(), "\n"), true
+}
+
+func writeDiffLines(b *strings.Builder, prefix, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		b.WriteString(prefix + line + "\n")
+	}
+}
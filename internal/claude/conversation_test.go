@@ -0,0 +1,80 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConversation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(sampleTranscript), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading the same file twice simulates a resumed conversation that
+	// repeats earlier entries
+	conv, err := LoadConversation([]string{path, path})
+	if err != nil {
+		t.Fatalf("LoadConversation: %v", err)
+	}
+
+	var kinds []string
+	for _, item := range conv.Items {
+		kinds = append(kinds, item.Kind)
+	}
+	want := []string{ItemPrompt, ItemReply, ItemTool, ItemPrompt, ItemReply}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected items %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected items %v, got %v", want, kinds)
+		}
+	}
+
+	tool := conv.Items[2]
+	if tool.Tool != "Bash" || tool.Summary != "go test" || tool.Output != "ok" {
+		t.Errorf("unexpected tool item %+v", tool)
+	}
+
+	conv.RedactToolOutput()
+	if conv.Items[2].Output != "[redacted]" {
+		t.Errorf("expected redacted output, got %q", conv.Items[2].Output)
+	}
+}
+
+func TestEditDiff(t *testing.T) {
+	file, diff, ok := editDiff("Edit", []byte(`{"file_path":"a.go","old_string":"x := 1\n","new_string":"x := 2\ny := 3\n"}`))
+	if !ok || file != "a.go" {
+		t.Fatalf("expected edit of a.go, got %q ok=%v", file, ok)
+	}
+	want := "--- a.go\n+++ a.go\n@@\n-x := 1\n+x := 2\n+y := 3"
+	if diff != want {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", want, diff)
+	}
+
+	if _, _, ok := editDiff("Bash", []byte(`{"command":"ls"}`)); ok {
+		t.Error("Bash should not be treated as an edit")
+	}
+}
+
+func TestTruncateLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		max      int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"multi\nline   prompt", 20, "multi line prompt"},
+		{"a prompt that is too long", 10, "a promp..."},
+		{"héllo wörld ünïcode", 10, "héllo w..."},
+		{"日本語のプロンプトです", 8, "日本語のプ..."},
+	}
+
+	for _, tt := range tests {
+		if got := TruncateLine(tt.input, tt.max); got != tt.expected {
+			t.Errorf("TruncateLine(%q, %d) = %q, want %q", tt.input, tt.max, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/emaland/ccs/internal/git"
+)
+
+// hookStateFile is stored in the worktree's git directory so it never shows
+// up as an untracked file and disappears along with the worktree
+const hookStateFile = "ccs-claude-state.json"
+
+// Hook events that ccs registers with Claude Code
+const (
+	EventNotification     = "Notification"
+	EventStop             = "Stop"
+	EventUserPromptSubmit = "UserPromptSubmit"
+	EventPreToolUse       = "PreToolUse"
+)
+
+// HookEvents lists the Claude Code hook events that drive state tracking
+var HookEvents = []string{
+	EventNotification,
+	EventStop,
+	EventUserPromptSubmit,
+	EventPreToolUse,
+}
+
+// HookState is the Claude state last recorded by a Claude Code hook
+type HookState struct {
+	State          State     `json:"state"`
+	Event          string    `json:"event"`
+	Message        string    `json:"message,omitempty"`
+	SessionID      string    `json:"session_id,omitempty"`
+	TranscriptPath string    `json:"transcript_path,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// StateForEvent maps a Claude Code hook event to the state it implies
+func StateForEvent(event string) (State, bool) {
+	switch strings.ToLower(event) {
+	case strings.ToLower(EventUserPromptSubmit), strings.ToLower(EventPreToolUse):
+		return StateRunning, true
+	case strings.ToLower(EventNotification):
+		// Sent when Claude needs permission or has been waiting for input
+		return StateWaiting, true
+	case strings.ToLower(EventStop):
+		return StateIdle, true
+	default:
+		return StateUnknown, false
+	}
+}
+
+// ReadHookState reads the hook state recorded for a worktree
+func ReadHookState(worktree string) (*HookState, error) {
+	path, err := hookStatePath(worktree)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var hs HookState
+	if err := json.Unmarshal(data, &hs); err != nil {
+		return nil, err
+	}
+	return &hs, nil
+}
+
+// WriteHookState records hook state for a worktree
+func WriteHookState(worktree string, hs HookState) error {
+	path, err := hookStatePath(worktree)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(hs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Hooks fire concurrently, so write to a temp file and rename into place
+	tmp, err := os.CreateTemp(filepath.Dir(path), hookStateFile+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func hookStatePath(worktree string) (string, error) {
+	gitDir, err := git.GitDir(worktree)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, hookStateFile), nil
+}
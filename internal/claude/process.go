@@ -0,0 +1,176 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Process describes a running Claude process
+type Process struct {
+	PID     int
+	PPID    int
+	State   string // Kernel scheduler state, e.g. "S" or "R"
+	Cwd     string
+	Cmdline []string
+	Started time.Time // Zero if unknown
+}
+
+// ProcessTable is a snapshot of the Claude processes on this machine.
+// A single scan can answer queries for any number of sessions.
+type ProcessTable struct {
+	procs []Process
+}
+
+// ScanProcesses takes a snapshot of all running Claude processes
+func ScanProcesses() (*ProcessTable, error) {
+	procs, err := listClaudeProcesses()
+	if err != nil {
+		return nil, err
+	}
+	return newProcessTable(procs), nil
+}
+
+// newProcessTable builds a table from the given processes, dropping Claude
+// processes that were spawned by another Claude process so each session
+// reports only its top-level instance.
+func newProcessTable(procs []Process) *ProcessTable {
+	byPID := make(map[int]bool, len(procs))
+	for _, p := range procs {
+		byPID[p.PID] = true
+	}
+
+	t := &ProcessTable{}
+	for _, p := range procs {
+		if byPID[p.PPID] {
+			continue
+		}
+		t.procs = append(t.procs, p)
+	}
+	return t
+}
+
+// InPath returns the Claude processes whose cwd is the given path or one of
+// its subdirectories
+func (t *ProcessTable) InPath(path string) []Process {
+	if t == nil {
+		return nil
+	}
+
+	root := resolvePath(path)
+	var result []Process
+	for _, p := range t.procs {
+		if isWithin(root, p.Cwd) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// PID returns the PID of the first Claude process running in path, or 0
+func (t *ProcessTable) PID(path string) int {
+	procs := t.InPath(path)
+	if len(procs) == 0 {
+		return 0
+	}
+	return procs[0].PID
+}
+
+// State returns the Claude state for path. State recorded by Claude Code
+// hooks is preferred; process heuristics are only used without hooks.
+func (t *ProcessTable) State(path string) State {
+	procs := t.InPath(path)
+	if len(procs) == 0 {
+		return StateIdle
+	}
+
+	// State recorded before the newest process started was left by one
+	// that has gone, possibly mid-tool-call
+	if hs, err := ReadHookState(path); err == nil && !hs.UpdatedAt.Before(newestStart(procs)) {
+		return hs.State
+	}
+
+	// Check if process is waiting for input (simplified check)
+	if isWaitingForInput(procs[0]) {
+		return StateWaiting
+	}
+
+	return StateRunning
+}
+
+// newestStart returns when the most recently started of procs started
+func newestStart(procs []Process) time.Time {
+	var newest time.Time
+	for _, p := range procs {
+		if p.Started.After(newest) {
+			newest = p.Started
+		}
+	}
+	return newest
+}
+
+// Stop sends SIGTERM to every Claude process running in path
+func (t *ProcessTable) Stop(path string) error {
+	for _, p := range t.InPath(path) {
+		process, err := os.FindProcess(p.PID)
+		if err != nil {
+			return err
+		}
+		if err := process.Signal(syscall.SIGTERM); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isClaudeCommand reports whether a command line belongs to Claude Code,
+// either as the native binary or as a node script
+func isClaudeCommand(cmdline []string) bool {
+	if len(cmdline) == 0 {
+		return false
+	}
+
+	if filepath.Base(cmdline[0]) == "claude" {
+		return true
+	}
+
+	if filepath.Base(cmdline[0]) == "node" && len(cmdline) > 1 {
+		script := cmdline[1]
+		return filepath.Base(script) == "claude" || strings.Contains(script, "@anthropic-ai/claude-code")
+	}
+
+	return false
+}
+
+// isWaitingForInput checks if a process is waiting for input
+func isWaitingForInput(p Process) bool {
+	// If it's in interruptible sleep (S), likely waiting
+	// This is a simplified heuristic
+	return strings.HasPrefix(p.State, "S")
+}
+
+// resolvePath returns the absolute, symlink-free form of path so it can be
+// compared with the cwd reported by the kernel
+func resolvePath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		return resolved
+	}
+	return absPath
+}
+
+// isWithin reports whether path is root or lies beneath it
+func isWithin(root, path string) bool {
+	if path == "" {
+		return false
+	}
+	if path == root {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
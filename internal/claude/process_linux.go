@@ -0,0 +1,146 @@
+//go:build linux
+
+package claude
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const procRoot = "/proc"
+
+// clockTicks is the kernel's USER_HZ, the unit of process start times in
+// /proc, which is 100 on every architecture Linux exposes to userspace
+const clockTicks = 100
+
+// listClaudeProcesses walks /proc and returns every Claude process
+func listClaudeProcesses() ([]Process, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	boot := bootTime()
+
+	var procs []Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		p, ok := readProcess(pid, boot)
+		if !ok {
+			continue
+		}
+		procs = append(procs, p)
+	}
+
+	return procs, nil
+}
+
+// readProcess reads a single /proc entry, returning false if the process is
+// not Claude or has exited or is not accessible. Start times are only set
+// if the boot time is known.
+func readProcess(pid int, boot time.Time) (Process, bool) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return Process{}, false
+	}
+	comm, state, ppid, startTicks, err := parseProcStat(string(stat))
+	if err != nil {
+		return Process{}, false
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return Process{}, false
+	}
+	cmdline := parseCmdline(raw)
+
+	if comm != "claude" && !isClaudeCommand(cmdline) {
+		return Process{}, false
+	}
+
+	cwd, err := os.Readlink(filepath.Join(dir, "cwd"))
+	if err != nil {
+		return Process{}, false
+	}
+
+	p := Process{
+		PID:     pid,
+		PPID:    ppid,
+		State:   state,
+		Cwd:     cwd,
+		Cmdline: cmdline,
+	}
+	if !boot.IsZero() && startTicks > 0 {
+		p.Started = boot.Add(time.Duration(startTicks) * time.Second / clockTicks)
+	}
+	return p, true
+}
+
+// bootTime reads when the system booted from the btime line of /proc/stat,
+// returning the zero time if it can't
+func bootTime() time.Time {
+	data, err := os.ReadFile(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			if secs, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64); err == nil {
+				return time.Unix(secs, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// parseProcStat extracts comm, state, ppid and the start time in clock
+// ticks since boot (field 22, zero if missing) from /proc/<pid>/stat.
+// The comm field may itself contain spaces and parentheses, so it is
+// delimited by the first "(" and the last ")".
+func parseProcStat(stat string) (comm, state string, ppid int, startTicks uint64, err error) {
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return "", "", 0, 0, fmt.Errorf("malformed stat: %q", stat)
+	}
+	comm = stat[open+1 : end]
+
+	// Fields after comm start at field 3, state
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return "", "", 0, 0, fmt.Errorf("malformed stat: %q", stat)
+	}
+	state = fields[0]
+	ppid, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return "", "", 0, 0, fmt.Errorf("malformed stat: %q", stat)
+	}
+	if len(fields) > 19 {
+		startTicks, _ = strconv.ParseUint(fields[19], 10, 64)
+	}
+	return comm, state, ppid, startTicks, nil
+}
+
+// parseCmdline splits the NUL-separated contents of /proc/<pid>/cmdline
+func parseCmdline(raw []byte) []string {
+	raw = bytes.TrimRight(raw, "\x00")
+	if len(raw) == 0 {
+		return nil
+	}
+	var args []string
+	for _, arg := range bytes.Split(raw, []byte{0}) {
+		args = append(args, string(arg))
+	}
+	return args
+}
@@ -0,0 +1,135 @@
+//go:build linux
+
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseProcStat(t *testing.T) {
+	tests := []struct {
+		input      string
+		comm       string
+		state      string
+		ppid       int
+		startTicks uint64
+		wantErr    bool
+	}{
+		{"1234 (claude) S 1000 1234 1234 0 -1", "claude", "S", 1000, 0, false},
+		{"42 (node) R 1 42 42 0 -1", "node", "R", 1, 0, false},
+		{"7 (weird (name) x) S 3 7 7", "weird (name) x", "S", 3, 0, false},
+		{"99 (claude) S 98 99 99 34816 99 4194304 1200 0 0 0 15 3 0 0 20 0 11 0 73125 1144958976", "claude", "S", 98, 73125, false},
+		{"garbage", "", "", 0, 0, true},
+		{"9 (x)", "", "", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		comm, state, ppid, startTicks, err := parseProcStat(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("input %q: error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if comm != tt.comm || state != tt.state || ppid != tt.ppid || startTicks != tt.startTicks {
+			t.Errorf("input %q: got (%q, %q, %d, %d), want (%q, %q, %d, %d)",
+				tt.input, comm, state, ppid, startTicks, tt.comm, tt.state, tt.ppid, tt.startTicks)
+		}
+	}
+}
+
+func TestParseCmdline(t *testing.T) {
+	got := parseCmdline([]byte("node\x00/usr/lib/node_modules/@anthropic-ai/claude-code/cli.js\x00--continue\x00"))
+	want := []string{"node", "/usr/lib/node_modules/@anthropic-ai/claude-code/cli.js", "--continue"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := parseCmdline(nil); got != nil {
+		t.Errorf("expected nil for empty cmdline, got %v", got)
+	}
+}
+
+func TestIsClaudeCommand(t *testing.T) {
+	tests := []struct {
+		cmdline  []string
+		expected bool
+	}{
+		{[]string{"claude"}, true},
+		{[]string{"/usr/local/bin/claude", "--continue"}, true},
+		{[]string{"node", "/usr/local/bin/claude"}, true},
+		{[]string{"node", "/opt/node_modules/@anthropic-ai/claude-code/cli.js"}, true},
+		{[]string{"node", "server.js"}, false},
+		{[]string{"vim", "claude.go"}, false},
+		{[]string{"grep", "claude"}, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := isClaudeCommand(tt.cmdline); got != tt.expected {
+			t.Errorf("isClaudeCommand(%v) = %v, want %v", tt.cmdline, got, tt.expected)
+		}
+	}
+}
+
+func TestProcessTableInPath(t *testing.T) {
+	table := newProcessTable([]Process{
+		{PID: 10, PPID: 1, Cwd: "/work/.ccs/repo/feature"},
+		{PID: 11, PPID: 10, Cwd: "/work/.ccs/repo/feature"}, // Child of 10, collapsed
+		{PID: 20, PPID: 1, Cwd: "/work/.ccs/repo/bugfix/src/pkg"},
+		{PID: 30, PPID: 1, Cwd: "/work/.ccs/repo/feature-two"},
+	})
+
+	tests := []struct {
+		path     string
+		expected []int
+	}{
+		{"/work/.ccs/repo/feature", []int{10}},
+		{"/work/.ccs/repo/bugfix", []int{20}},
+		{"/work/.ccs/repo/feature-two", []int{30}},
+		{"/work/.ccs/repo/missing", nil},
+	}
+
+	for _, tt := range tests {
+		var pids []int
+		for _, p := range table.InPath(tt.path) {
+			pids = append(pids, p.PID)
+		}
+		if !reflect.DeepEqual(pids, tt.expected) {
+			t.Errorf("InPath(%q) = %v, want %v", tt.path, pids, tt.expected)
+		}
+	}
+}
+
+func TestProcessTableStateIgnoresStaleHookState(t *testing.T) {
+	worktree, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(worktree, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+	table := newProcessTable([]Process{{PID: 10, PPID: 1, Cwd: worktree, Started: started}})
+
+	tests := []struct {
+		name      string
+		updatedAt time.Time
+		expected  State
+	}{
+		{"recorded by the running process", started.Add(time.Second), StateWaiting},
+		{"left by an earlier process", started.Add(-time.Minute), StateRunning},
+	}
+
+	for _, tt := range tests {
+		if err := WriteHookState(worktree, HookState{State: StateWaiting, UpdatedAt: tt.updatedAt}); err != nil {
+			t.Fatal(err)
+		}
+		if got := table.State(worktree); got != tt.expected {
+			t.Errorf("%s: State = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
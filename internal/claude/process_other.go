@@ -0,0 +1,112 @@
+//go:build !linux
+
+package claude
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listClaudeProcesses finds Claude processes with ps, then resolves all of
+// their working directories with a single lsof call
+func listClaudeProcesses() ([]Process, error) {
+	out, err := exec.Command("ps", "-axo", "pid=,ppid=,stat=,etime=,command=").Output()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	var procs []Process
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+
+		cmdline := fields[4:]
+		if !isClaudeCommand(cmdline) {
+			continue
+		}
+
+		p := Process{
+			PID:     pid,
+			PPID:    ppid,
+			State:   fields[2],
+			Cmdline: cmdline,
+		}
+		// Elapsed time is in whole seconds, so the start may be up to a
+		// second earlier than it appears
+		if elapsed, ok := parseElapsed(fields[3]); ok {
+			p.Started = now.Add(-elapsed - time.Second)
+		}
+		procs = append(procs, p)
+	}
+
+	if len(procs) == 0 {
+		return nil, nil
+	}
+
+	cwds := getProcessCwds(procs)
+	for i := range procs {
+		procs[i].Cwd = cwds[procs[i].PID]
+	}
+
+	return procs, nil
+}
+
+// getProcessCwds gets the current working directory of each process
+func getProcessCwds(procs []Process) map[int]string {
+	pids := make([]string, len(procs))
+	for i, p := range procs {
+		pids[i] = strconv.Itoa(p.PID)
+	}
+
+	// lsof exits non-zero if any PID has gone away, so use whatever it printed
+	out, _ := exec.Command("lsof", "-a", "-p", strings.Join(pids, ","), "-d", "cwd", "-Fpn").Output()
+
+	cwds := make(map[int]string)
+	pid := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "p"):
+			pid, _ = strconv.Atoi(line[1:])
+		case strings.HasPrefix(line, "n") && pid != 0:
+			cwds[pid] = line[1:]
+		}
+	}
+	return cwds
+}
+
+// parseElapsed parses the elapsed time printed by ps, [[dd-]hh:]mm:ss
+func parseElapsed(s string) (time.Duration, bool) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, false
+		}
+		days, s = n, rest
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	secs := 0
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		secs = secs*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(secs)*time.Second, true
+}
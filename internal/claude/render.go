@@ -0,0 +1,188 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// Transcript export formats
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// Render writes the conversation to w in the given format
+func (c *Conversation) Render(w io.Writer, format string) error {
+	switch format {
+	case FormatMarkdown, "markdown":
+		return c.renderMarkdown(w)
+	case FormatHTML:
+		return c.renderHTML(w)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	default:
+		return fmt.Errorf("unsupported format: %s (supported: md, html, json)", format)
+	}
+}
+
+func (c *Conversation) renderMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Session %s\n\n", c.Session)
+	fmt.Fprintf(&b, "- Path: `%s`\n", c.Path)
+	if c.Model != "" {
+		fmt.Fprintf(&b, "- Model: %s\n", c.Model)
+	}
+	if !c.Started.IsZero() {
+		fmt.Fprintf(&b, "- Started: %s\n", c.Started.Local().Format(time.RFC1123))
+		fmt.Fprintf(&b, "- Ended: %s\n", c.Ended.Local().Format(time.RFC1123))
+	}
+
+	for _, item := range c.Items {
+		switch item.Kind {
+		case ItemPrompt:
+			fmt.Fprintf(&b, "\n## User\n\n%s\n", item.Text)
+
+		case ItemReply:
+			fmt.Fprintf(&b, "\n## Claude\n\n%s\n", item.Text)
+
+		case ItemEdit:
+			fmt.Fprintf(&b, "\n<details>\n<summary>%s <code>%s</code></summary>\n\n", item.Tool, template.HTMLEscapeString(item.File))
+			b.WriteString(fence("diff", item.Diff))
+			b.WriteString("\n</details>\n")
+
+		case ItemTool:
+			fmt.Fprintf(&b, "\n<details>\n<summary>%s <code>%s</code></summary>\n\n", item.Tool, template.HTMLEscapeString(TruncateLine(item.Summary, 80)))
+			if len(item.Input) > 0 {
+				b.WriteString(fence("json", prettyJSON(item.Input)))
+			}
+			if item.Output != "" {
+				label := "Output"
+				if item.IsError {
+					label = "Error"
+				}
+				fmt.Fprintf(&b, "\n%s:\n\n", label)
+				b.WriteString(fence("", item.Output))
+			}
+			b.WriteString("\n</details>\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"time":      func(t time.Time) string { return t.Local().Format(time.RFC1123) },
+	"line":      func(s string) string { return TruncateLine(s, 80) },
+	"json":      func(raw json.RawMessage) string { return prettyJSON(raw) },
+	"diffLines": diffLines,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Session {{.Session}}</title>
+<style>
+body { font-family: -apple-system, sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; color: #222; }
+.meta { color: #666; font-size: 0.9em; }
+.prompt, .reply { margin: 1em 0; padding: 0.5em 1em; border-radius: 6px; white-space: pre-wrap; }
+.prompt { background: #eef4ff; }
+.reply { background: #f6f6f6; }
+.role { font-weight: bold; display: block; margin-bottom: 0.3em; }
+details { margin: 0.5em 0; border-left: 3px solid #ccc; padding-left: 0.8em; }
+summary { cursor: pointer; font-family: monospace; }
+pre { background: #fafafa; padding: 0.5em; overflow-x: auto; }
+.error { color: #b00; }
+.add { color: #070; }
+.del { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Session {{.Session}}</h1>
+<p class="meta">{{.Path}}{{if .Model}} &middot; {{.Model}}{{end}}{{if not .Started.IsZero}} &middot; {{time .Started}} &ndash; {{time .Ended}}{{end}}</p>
+{{range .Items}}
+{{- if eq .Kind "prompt"}}
+<div class="prompt"><span class="role">User</span>{{.Text}}</div>
+{{- else if eq .Kind "reply"}}
+<div class="reply"><span class="role">Claude</span>{{.Text}}</div>
+{{- else if eq .Kind "edit"}}
+<details><summary>{{.Tool}} {{.File}}</summary>
+<pre>{{range diffLines .Diff}}<span class="{{.Class}}">{{.Text}}</span>
+{{end}}</pre>
+</details>
+{{- else if eq .Kind "tool"}}
+<details><summary>{{.Tool}} {{line .Summary}}</summary>
+{{if .Input}}<pre>{{json .Input}}</pre>{{end}}
+{{if .Output}}<pre{{if .IsError}} class="error"{{end}}>{{.Output}}</pre>{{end}}
+</details>
+{{- end}}
+{{end}}
+</body>
+</html>
+`))
+
+type diffLine struct {
+	Class string
+	Text  string
+}
+
+func diffLines(diff string) []diffLine {
+	var lines []diffLine
+	for _, line := range strings.Split(diff, "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		lines = append(lines, diffLine{Class: class, Text: line})
+	}
+	return lines
+}
+
+func (c *Conversation) renderHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, c)
+}
+
+// fence wraps text in a Markdown code fence long enough not to collide with
+// any backtick run inside it
+func fence(lang, text string) string {
+	marker := "```"
+	for strings.Contains(text, marker) {
+		marker += "`"
+	}
+	return marker + lang + "\n" + strings.TrimSuffix(text, "\n") + "\n" + marker + "\n"
+}
+
+func prettyJSON(raw json.RawMessage) string {
+	var v interface{}
+	if json.Unmarshal(raw, &v) != nil {
+		return string(raw)
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return string(raw)
+	}
+	return string(out)
+}
+
+// TruncateLine shortens s, such as a prompt or tool summary, to a single
+// line of at most max characters. It counts runes rather than bytes so
+// multi-byte characters are never split.
+func TruncateLine(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
@@ -0,0 +1,171 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HookCommandPrefix identifies hook commands installed by ccs
+const HookCommandPrefix = "ccs _hook"
+
+type hookCommand struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Timeout int    `json:"timeout,omitempty"`
+}
+
+type hookMatcher struct {
+	Matcher string        `json:"matcher,omitempty"`
+	Hooks   []hookCommand `json:"hooks"`
+}
+
+// SettingsPath returns the path of the Claude Code project settings file
+func SettingsPath(projectRoot string) string {
+	return filepath.Join(projectRoot, ".claude", "settings.json")
+}
+
+// UserSettingsPath returns the path of the Claude Code user settings file,
+// which applies to every project, in CLAUDE_CONFIG_DIR if set
+func UserSettingsPath() (string, error) {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "settings.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".claude", "settings.json"), nil
+}
+
+// InstallHooks registers a "ccs _hook <event>" command for every event in
+// HookEvents, preserving all other settings and hooks in the file
+func InstallHooks(settingsPath string) error {
+	settings, hooks, err := loadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range HookEvents {
+		command := HookCommandPrefix + " " + event
+		matchers := removeCCSHooks(hooks[event])
+		matchers = append(matchers, hookMatcher{
+			Hooks: []hookCommand{{Type: "command", Command: command}},
+		})
+		hooks[event] = matchers
+	}
+
+	return saveSettings(settingsPath, settings, hooks)
+}
+
+// UninstallHooks removes all ccs hook commands from the settings file
+func UninstallHooks(settingsPath string) error {
+	settings, hooks, err := loadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	for event, matchers := range hooks {
+		matchers = removeCCSHooks(matchers)
+		if len(matchers) == 0 {
+			delete(hooks, event)
+		} else {
+			hooks[event] = matchers
+		}
+	}
+
+	return saveSettings(settingsPath, settings, hooks)
+}
+
+// InstalledHooks returns the events that have a ccs hook command registered
+func InstalledHooks(settingsPath string) ([]string, error) {
+	_, hooks, err := loadSettings(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []string
+	for _, event := range HookEvents {
+		for _, m := range hooks[event] {
+			if hasCCSHook(m) {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+	return events, nil
+}
+
+func loadSettings(path string) (map[string]json.RawMessage, map[string][]hookMatcher, error) {
+	settings := map[string]json.RawMessage{}
+	hooks := map[string][]hookMatcher{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, hooks, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, nil, err
+	}
+	if raw, ok := settings["hooks"]; ok {
+		if err := json.Unmarshal(raw, &hooks); err != nil {
+			return nil, nil, err
+		}
+	}
+	return settings, hooks, nil
+}
+
+func saveSettings(path string, settings map[string]json.RawMessage, hooks map[string][]hookMatcher) error {
+	if len(hooks) == 0 {
+		delete(settings, "hooks")
+	} else {
+		raw, err := json.Marshal(hooks)
+		if err != nil {
+			return err
+		}
+		settings["hooks"] = raw
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// removeCCSHooks strips ccs commands from matchers, dropping matchers that
+// are left empty
+func removeCCSHooks(matchers []hookMatcher) []hookMatcher {
+	var result []hookMatcher
+	for _, m := range matchers {
+		var kept []hookCommand
+		for _, h := range m.Hooks {
+			if !strings.HasPrefix(h.Command, HookCommandPrefix) {
+				kept = append(kept, h)
+			}
+		}
+		if len(kept) > 0 {
+			m.Hooks = kept
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func hasCCSHook(m hookMatcher) bool {
+	for _, h := range m.Hooks {
+		if strings.HasPrefix(h.Command, HookCommandPrefix) {
+			return true
+		}
+	}
+	return false
+}
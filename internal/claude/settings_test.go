@@ -0,0 +1,81 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInstallUninstallHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+	existing := `{"model": "opus", "hooks": {"Stop": [{"hooks": [{"type": "command", "command": "notify-send done"}]}]}}`
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Installing twice must not duplicate entries
+	for i := 0; i < 2; i++ {
+		if err := InstallHooks(path); err != nil {
+			t.Fatalf("InstallHooks: %v", err)
+		}
+	}
+
+	events, err := InstalledHooks(path)
+	if err != nil {
+		t.Fatalf("InstalledHooks: %v", err)
+	}
+	if !reflect.DeepEqual(events, HookEvents) {
+		t.Errorf("expected %v installed, got %v", HookEvents, events)
+	}
+
+	_, hooks, err := loadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks[EventStop]) != 2 {
+		t.Errorf("expected user Stop hook to be kept alongside ccs hook, got %+v", hooks[EventStop])
+	}
+
+	if err := UninstallHooks(path); err != nil {
+		t.Fatalf("UninstallHooks: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if strings.Contains(content, HookCommandPrefix) {
+		t.Errorf("ccs hooks left after uninstall:\n%s", content)
+	}
+	if !strings.Contains(content, "notify-send done") || !strings.Contains(content, `"model": "opus"`) {
+		t.Errorf("user settings lost after uninstall:\n%s", content)
+	}
+}
+
+func TestStateForEvent(t *testing.T) {
+	tests := []struct {
+		event    string
+		expected State
+		ok       bool
+	}{
+		{EventUserPromptSubmit, StateRunning, true},
+		{EventPreToolUse, StateRunning, true},
+		{EventNotification, StateWaiting, true},
+		{EventStop, StateIdle, true},
+		{"stop", StateIdle, true},
+		{"SessionStart", StateUnknown, false},
+	}
+
+	for _, tt := range tests {
+		state, ok := StateForEvent(tt.event)
+		if state != tt.expected || ok != tt.ok {
+			t.Errorf("StateForEvent(%q) = (%v, %v), want (%v, %v)", tt.event, state, ok, tt.expected, tt.ok)
+		}
+	}
+}
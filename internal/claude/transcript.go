@@ -0,0 +1,389 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emaland/ccs/internal/config"
+)
+
+// Usage contains token counts reported for an assistant message
+type Usage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CacheCreationTokens int `json:"cache_creation_input_tokens"`
+	CacheReadTokens     int `json:"cache_read_input_tokens"`
+}
+
+// ContentBlock is one block of message content
+type ContentBlock struct {
+	Type      string          `json:"type"` // text, thinking, tool_use, tool_result, image
+	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"` // tool_result: string or blocks
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// Message is the API message carried by a transcript entry
+type Message struct {
+	ID      string         `json:"id,omitempty"`
+	Role    string         `json:"role"`
+	Model   string         `json:"model,omitempty"`
+	Content []ContentBlock `json:"content"`
+	Usage   *Usage         `json:"usage,omitempty"`
+}
+
+// UnmarshalJSON accepts content given either as a plain string or as blocks
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID      string          `json:"id"`
+		Role    string          `json:"role"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+		Usage   *Usage          `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.ID, m.Role, m.Model, m.Usage = raw.ID, raw.Role, raw.Model, raw.Usage
+	m.Content = nil
+
+	content := bytes.TrimSpace(raw.Content)
+	if len(content) == 0 || content[0] == 'n' {
+		return nil
+	}
+	if content[0] == '"' {
+		var text string
+		if err := json.Unmarshal(content, &text); err != nil {
+			return err
+		}
+		m.Content = []ContentBlock{{Type: "text", Text: text}}
+		return nil
+	}
+	return json.Unmarshal(content, &m.Content)
+}
+
+// Text returns the concatenated text blocks of the message
+func (m *Message) Text() string {
+	var parts []string
+	for _, b := range m.Content {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// IsPrompt reports whether the message is a prompt typed by the user, as
+// opposed to tool results or command output that Claude records as user
+// messages
+func (m *Message) IsPrompt() bool {
+	if m.Role != "user" {
+		return false
+	}
+	for _, b := range m.Content {
+		if b.Type == "tool_result" {
+			return false
+		}
+	}
+	text := strings.TrimSpace(m.Text())
+	return text != "" && !strings.HasPrefix(text, "<")
+}
+
+// Entry is a single line of a Claude transcript
+type Entry struct {
+	Type        string    `json:"type"` // user, assistant, summary, system
+	UUID        string    `json:"uuid,omitempty"`
+	SessionID   string    `json:"sessionId,omitempty"`
+	Cwd         string    `json:"cwd,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	IsMeta      bool      `json:"isMeta,omitempty"`
+	IsSidechain bool      `json:"isSidechain,omitempty"`
+	Message     *Message  `json:"message,omitempty"`
+}
+
+// TranscriptSummary aggregates usage across a worktree's transcripts
+type TranscriptSummary struct {
+	Model        string    `json:"model,omitempty"`
+	Usage        Usage     `json:"usage"`
+	Cost         float64   `json:"cost"`
+	Messages     int       `json:"messages"`
+	LastPrompt   string    `json:"last_prompt,omitempty"`
+	LastActivity time.Time `json:"last_activity"`
+	Files        []string  `json:"files,omitempty"`
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// ProjectsDir returns the directory where Claude Code stores transcripts
+func ProjectsDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// ProjectDir returns the transcript directory Claude uses for a path.
+// Claude names it after the absolute path with every character other than
+// letters and digits replaced by "-".
+func ProjectDir(path string) string {
+	return filepath.Join(ProjectsDir(), nonAlphanumeric.ReplaceAllString(path, "-"))
+}
+
+// MoveTranscripts moves Claude's transcripts for a directory that has been
+// moved from oldPath to newPath, so "claude --continue" in the new location
+// picks up the conversation. Files already at the destination are kept.
+func MoveTranscripts(oldPath, newPath string) error {
+	// Claude may have recorded the resolved path; the old one no longer
+	// exists, so resolve its parent
+	pairs := [][2]string{{oldPath, newPath}}
+	resolvedOld := filepath.Join(resolvePath(filepath.Dir(oldPath)), filepath.Base(oldPath))
+	if resolvedOld != oldPath {
+		pairs = append(pairs, [2]string{resolvedOld, resolvePath(newPath)})
+	}
+
+	for _, p := range pairs {
+		src, dst := ProjectDir(p[0]), ProjectDir(p[1])
+		entries, err := os.ReadDir(src)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dst, 0700); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			target := filepath.Join(dst, e.Name())
+			if _, err := os.Lstat(target); err == nil {
+				continue
+			}
+			if err := os.Rename(filepath.Join(src, e.Name()), target); err != nil {
+				return err
+			}
+		}
+		os.Remove(src) // Only succeeds if everything moved
+	}
+	return nil
+}
+
+// CopyLatestTranscript copies the most recent conversation in one worktree
+// to another, where "claude --continue" will pick it up. It returns false
+// if there is no conversation to copy.
+func CopyLatestTranscript(from, to string) (bool, error) {
+	files, err := TranscriptFiles(from)
+	if err != nil || len(files) == 0 {
+		return false, err
+	}
+	latest := files[len(files)-1]
+
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return false, err
+	}
+	dst := ProjectDir(absTo)
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(latest)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(filepath.Join(dst, filepath.Base(latest)), data, 0600)
+}
+
+// TranscriptFiles returns the transcript files for a worktree, oldest first
+func TranscriptFiles(worktree string) ([]string, error) {
+	absPath, err := filepath.Abs(worktree)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claude records the resolved cwd, which differs when the path
+	// contains a symlink (e.g. /tmp on macOS)
+	dirs := []string{ProjectDir(absPath)}
+	if resolved := resolvePath(absPath); resolved != absPath {
+		dirs = append(dirs, ProjectDir(resolved))
+	}
+
+	type transcriptFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files []transcriptFile
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				continue
+			}
+			files = append(files, transcriptFile{path: m, modTime: info.ModTime()})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	result := make([]string, len(files))
+	for i, f := range files {
+		result[i] = f.path
+	}
+	return result, nil
+}
+
+// ReadTranscript streams the entries of a transcript file to fn. Lines that
+// cannot be parsed are skipped, since the format is not versioned.
+func ReadTranscript(path string, fn func(Entry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var entry Entry
+			if json.Unmarshal(line, &entry) == nil {
+				if ferr := fn(entry); ferr != nil {
+					return ferr
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// SummarizeTranscript aggregates token usage, cost and activity for all of
+// a worktree's transcripts
+func SummarizeTranscript(worktree string, pricing map[string]config.ModelPrice) (*TranscriptSummary, error) {
+	files, err := TranscriptFiles(worktree)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return summarizeFiles(files, pricing)
+}
+
+func summarizeFiles(files []string, pricing map[string]config.ModelPrice) (*TranscriptSummary, error) {
+	summary := &TranscriptSummary{Files: files}
+
+	// Assistant replies are written once per content block with the same
+	// message ID, and resumed conversations repeat earlier entries, so
+	// count each message once using the last usage reported for it
+	assistant := map[string]*Message{}
+	var order []string
+	seenUser := map[string]bool{}
+	var lastPromptAt time.Time
+
+	for _, path := range files {
+		err := ReadTranscript(path, func(e Entry) error {
+			if e.Message == nil || e.IsMeta {
+				return nil
+			}
+			if e.Timestamp.After(summary.LastActivity) {
+				summary.LastActivity = e.Timestamp
+			}
+
+			switch e.Type {
+			case "assistant":
+				id := e.Message.ID
+				if id == "" {
+					id = e.UUID
+				}
+				if _, ok := assistant[id]; !ok {
+					order = append(order, id)
+				}
+				assistant[id] = e.Message
+
+			case "user":
+				if !e.Message.IsPrompt() {
+					return nil
+				}
+				if e.UUID == "" || !seenUser[e.UUID] {
+					seenUser[e.UUID] = true
+					summary.Messages++
+				}
+				if !e.Timestamp.Before(lastPromptAt) {
+					lastPromptAt = e.Timestamp
+					summary.LastPrompt = strings.TrimSpace(e.Message.Text())
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range order {
+		msg := assistant[id]
+		summary.Messages++
+		if msg.Model != "" && msg.Model != "<synthetic>" {
+			summary.Model = msg.Model
+		}
+		if msg.Usage == nil {
+			continue
+		}
+		summary.Usage.InputTokens += msg.Usage.InputTokens
+		summary.Usage.OutputTokens += msg.Usage.OutputTokens
+		summary.Usage.CacheCreationTokens += msg.Usage.CacheCreationTokens
+		summary.Usage.CacheReadTokens += msg.Usage.CacheReadTokens
+		summary.Cost += EstimateCost(msg.Model, *msg.Usage, pricing)
+	}
+
+	return summary, nil
+}
+
+// EstimateCost returns the cost in USD of usage for a model, using the
+// longest matching model prefix in the price table
+func EstimateCost(model string, usage Usage, pricing map[string]config.ModelPrice) float64 {
+	price, ok := lookupPrice(model, pricing)
+	if !ok {
+		return 0
+	}
+	const perMillion = 1_000_000.0
+	return (float64(usage.InputTokens)*price.Input +
+		float64(usage.OutputTokens)*price.Output +
+		float64(usage.CacheCreationTokens)*price.CacheWrite +
+		float64(usage.CacheReadTokens)*price.CacheRead) / perMillion
+}
+
+func lookupPrice(model string, pricing map[string]config.ModelPrice) (config.ModelPrice, bool) {
+	var best string
+	for prefix := range pricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return config.ModelPrice{}, false
+	}
+	return pricing[best], true
+}
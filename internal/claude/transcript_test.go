@@ -0,0 +1,115 @@
+package claude
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/emaland/ccs/internal/config"
+)
+
+const sampleTranscript = `{"type":"summary","summary":"Fix tests"}
+{"type":"user","uuid":"u1","timestamp":"2025-06-01T10:00:00.000Z","message":{"role":"user","content":"fix the failing tests"}}
+{"type":"assistant","uuid":"a1","timestamp":"2025-06-01T10:00:05.000Z","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"Looking."}],"usage":{"input_tokens":100,"output_tokens":10,"cache_creation_input_tokens":1000,"cache_read_input_tokens":0}}}
+{"type":"assistant","uuid":"a2","timestamp":"2025-06-01T10:00:06.000Z","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test"}}],"usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":1000,"cache_read_input_tokens":0}}}
+{"type":"user","uuid":"u2","timestamp":"2025-06-01T10:00:07.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}
+not json
+{"type":"user","uuid":"u3","timestamp":"2025-06-01T10:01:00.000Z","isMeta":true,"message":{"role":"user","content":"Caveat: meta"}}
+{"type":"user","uuid":"u4","timestamp":"2025-06-01T10:02:00.000Z","message":{"role":"user","content":[{"type":"text","text":"now commit it"}]}}
+{"type":"assistant","uuid":"a3","timestamp":"2025-06-01T10:02:10.000Z","message":{"id":"msg_2","role":"assistant","model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"Done."}],"usage":{"input_tokens":200,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":1000}}}
+`
+
+func TestSummarizeFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(sampleTranscript), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pricing := map[string]config.ModelPrice{
+		"claude-sonnet-4": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude":          {Input: 100, Output: 100},
+	}
+
+	summary, err := summarizeFiles([]string{path}, pricing)
+	if err != nil {
+		t.Fatalf("summarizeFiles: %v", err)
+	}
+
+	// msg_1 is counted once with its final usage
+	want := Usage{InputTokens: 300, OutputTokens: 70, CacheCreationTokens: 1000, CacheReadTokens: 1000}
+	if summary.Usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, summary.Usage)
+	}
+	if summary.Messages != 4 {
+		t.Errorf("expected 4 messages, got %d", summary.Messages)
+	}
+	if summary.Model != "claude-sonnet-4-5-20250929" {
+		t.Errorf("unexpected model %q", summary.Model)
+	}
+	if summary.LastPrompt != "now commit it" {
+		t.Errorf("unexpected last prompt %q", summary.LastPrompt)
+	}
+	if !summary.LastActivity.Equal(time.Date(2025, 6, 1, 10, 2, 10, 0, time.UTC)) {
+		t.Errorf("unexpected last activity %v", summary.LastActivity)
+	}
+
+	wantCost := (300*3 + 70*15 + 1000*3.75 + 1000*0.3) / 1_000_000.0
+	if math.Abs(summary.Cost-wantCost) > 1e-9 {
+		t.Errorf("expected cost %f, got %f", wantCost, summary.Cost)
+	}
+}
+
+func TestProjectDir(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", "/home/u/.claude")
+	got := ProjectDir("/home/u/.ccs/my_repo/feature")
+	want := "/home/u/.claude/projects/-home-u--ccs-my-repo-feature"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestMoveTranscripts(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+	root := t.TempDir()
+	oldPath, newPath := filepath.Join(root, "old"), filepath.Join(root, "new")
+
+	src := ProjectDir(oldPath)
+	if err := os.MkdirAll(filepath.Join(src, "s1"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"s1.jsonl", "s2.jsonl"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A conversation already started in the new location is kept
+	dst := ProjectDir(newPath)
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "s2.jsonl"), []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MoveTranscripts(oldPath, newPath); err != nil {
+		t.Fatalf("MoveTranscripts: %v", err)
+	}
+
+	for name, want := range map[string]string{"s1.jsonl": "s1.jsonl", "s2.jsonl": "new"} {
+		data, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, data)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dst, "s1")); err != nil || !info.IsDir() {
+		t.Errorf("expected s1 directory to be moved")
+	}
+	if _, err := os.Stat(filepath.Join(src, "s1.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("expected s1.jsonl to be gone from the old directory")
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -15,14 +17,125 @@ type Config struct {
 	Terminal         string `toml:"terminal"`     // "auto", "tmux", "kitty", "wezterm", "none"
 	DefaultBase      string `toml:"default_base"` // e.g., "main"
 
-	Hooks    HooksConfig    `toml:"hooks"`
-	Tmux     TmuxConfig     `toml:"terminal.tmux"`
-	Kitty    KittyConfig    `toml:"terminal.kitty"`
+	Worktree   WorktreeConfig   `toml:"worktree"`
+	Hooks      HooksConfig      `toml:"hooks"`
+	Tmux       TmuxConfig       `toml:"terminal.tmux"`
+	Kitty      KittyConfig      `toml:"terminal.kitty"`
+	Claude     ClaudeConfig     `toml:"claude"`
+	Sync       SyncConfig       `toml:"sync"`
+	State      StateConfig      `toml:"state"`
+	Import     ImportConfig     `toml:"import"`
+	Compare    CompareConfig    `toml:"compare"`
+	Checkpoint CheckpointConfig `toml:"checkpoint"`
+	GC         GCConfig         `toml:"gc"`
+	Ports      PortsConfig      `toml:"ports"`
 }
 
+// WorktreeConfig lists files git doesn't check out, such as .env or
+// dependency directories, to bring into new worktrees. Entries are glob
+// patterns relative to the repository root.
+type WorktreeConfig struct {
+	Copy    []string `toml:"copy"`    // Copied, e.g. ".env", "config/*.local.yml"
+	Symlink []string `toml:"symlink"` // Linked, so all sessions share them
+	Reflink []string `toml:"reflink"` // Cloned copy-on-write where supported, else copied, e.g. "node_modules"
+}
+
+// HooksConfig holds the shell commands run around session operations. Hooks
+// run in the session's worktree, or the repository root when there is none,
+// with the session described by CCS_* environment variables.
 type HooksConfig struct {
-	PostCreate string `toml:"post_create"`
-	PreFinish  string `toml:"pre_finish"`
+	PreCreate  Hook `toml:"pre_create"`
+	PostCreate Hook `toml:"post_create"`
+	PreSwitch  Hook `toml:"pre_switch"`
+	PostSwitch Hook `toml:"post_switch"`
+	PrePause   Hook `toml:"pre_pause"`
+	PostResume Hook `toml:"post_resume"`
+	PreFinish  Hook `toml:"pre_finish"`
+	PostFinish Hook `toml:"post_finish"`
+	PreDelete  Hook `toml:"pre_delete"`
+	PostDelete Hook `toml:"post_delete"`
+}
+
+// What happens when a hook command fails
+const (
+	HookFail = "fail" // Stop, and abort the operation if it hasn't happened yet
+	HookWarn = "warn" // Print a warning and carry on
+)
+
+// Hook is one or more shell commands, run in order. It can be written as a
+// command, a list of commands or a table:
+//
+//	post_create = "npm install"
+//	pre_finish = ["go vet ./...", "go test ./..."]
+//	post_switch = { commands = ["make dev"], timeout = "30s", on_failure = "warn" }
+type Hook struct {
+	Commands  []string
+	Timeout   time.Duration // Per command; 0 waits forever
+	OnFailure string        // HookFail or HookWarn; empty uses the hook's default
+}
+
+// UnmarshalTOML decodes a hook from a string, a list or a table
+func (h *Hook) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		h.Commands, _ = hookCommands([]interface{}{v})
+	case []interface{}:
+		commands, err := hookCommands(v)
+		if err != nil {
+			return err
+		}
+		h.Commands = commands
+	case map[string]interface{}:
+		for key, value := range v {
+			switch key {
+			case "commands":
+				list, ok := value.([]interface{})
+				if !ok {
+					list = []interface{}{value}
+				}
+				commands, err := hookCommands(list)
+				if err != nil {
+					return err
+				}
+				h.Commands = commands
+			case "timeout":
+				timeout, ok := value.(string)
+				if !ok {
+					return fmt.Errorf("hook timeout must be a duration such as \"30s\", got %v", value)
+				}
+				d, err := time.ParseDuration(timeout)
+				if err != nil {
+					return fmt.Errorf("hook timeout: %w", err)
+				}
+				h.Timeout = d
+			case "on_failure":
+				mode, _ := value.(string)
+				if mode != HookFail && mode != HookWarn {
+					return fmt.Errorf("hook on_failure must be %q or %q, got %v", HookFail, HookWarn, value)
+				}
+				h.OnFailure = mode
+			default:
+				return fmt.Errorf("unknown hook setting %q", key)
+			}
+		}
+	default:
+		return fmt.Errorf("a hook must be a command, a list of commands or a table, got %v", v)
+	}
+	return nil
+}
+
+func hookCommands(list []interface{}) ([]string, error) {
+	var commands []string
+	for _, item := range list {
+		command, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("hook commands must be strings, got %v", item)
+		}
+		if command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands, nil
 }
 
 type TmuxConfig struct {
@@ -35,6 +148,79 @@ type KittyConfig struct {
 	TabPrefix    string `toml:"tab_prefix"`
 }
 
+type SyncConfig struct {
+	Strategy string `toml:"strategy"` // "rebase" or "merge"
+}
+
+type StateConfig struct {
+	Backend string `toml:"backend"` // "json" or "sqlite"
+}
+
+type ImportConfig struct {
+	// Roots are glob patterns for repositories 'ccs import' scans by
+	// default, e.g. "~/src/*"
+	Roots []string `toml:"roots"`
+}
+
+type CompareConfig struct {
+	// Tests are shell commands 'ccs compare' runs in each session's
+	// worktree, e.g. "go test ./..."; exit status 0 is a pass
+	Tests []string `toml:"tests"`
+}
+
+type CheckpointConfig struct {
+	Auto bool `toml:"auto"` // Checkpoint whenever Claude stops (needs 'ccs hooks install')
+	Keep int  `toml:"keep"` // Automatic checkpoints to keep per session
+}
+
+type GCConfig struct {
+	// ArchiveAfterDays archives sessions nobody has switched to, resumed or
+	// looked at, and Claude hasn't worked in, for this many days; 0 disables
+	ArchiveAfterDays int `toml:"archive_after_days"`
+	// DeleteMergedAfterDays deletes sessions whose branch is merged into its
+	// base and that have been idle for this many days; 0 disables
+	DeleteMergedAfterDays int `toml:"delete_merged_after_days"`
+	// Auto runs 'ccs gc' at most once a day on ccs commands that change
+	// sessions in a repo
+	Auto bool `toml:"auto"`
+}
+
+// Enabled reports whether any retention rule is set
+func (c GCConfig) Enabled() bool {
+	return c.ArchiveAfterDays > 0 || c.DeleteMergedAfterDays > 0
+}
+
+type PortsConfig struct {
+	Start int `toml:"start"` // First port handed out to sessions
+	Block int `toml:"block"` // Ports reserved per session; 0 disables
+}
+
+type ClaudeConfig struct {
+	// Pricing maps a model ID prefix (e.g. "claude-sonnet-4") to its price.
+	// The longest matching prefix wins.
+	Pricing map[string]ModelPrice `toml:"pricing"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input      float64 `toml:"input"`
+	Output     float64 `toml:"output"`
+	CacheWrite float64 `toml:"cache_write"`
+	CacheRead  float64 `toml:"cache_read"`
+}
+
+// DefaultPricing returns list prices for current Claude models
+func DefaultPricing() map[string]ModelPrice {
+	return map[string]ModelPrice{
+		"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5},
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.5},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+		"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.1},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+	}
+}
+
 func Default() *Config {
 	homeDir, _ := os.UserHomeDir()
 	return &Config{
@@ -44,6 +230,22 @@ func Default() *Config {
 		AutoStartClaude:  true,
 		Terminal:         "auto",
 		DefaultBase:      "main",
+		Claude: ClaudeConfig{
+			Pricing: DefaultPricing(),
+		},
+		Sync: SyncConfig{
+			Strategy: "rebase",
+		},
+		State: StateConfig{
+			Backend: "json",
+		},
+		Checkpoint: CheckpointConfig{
+			Keep: 20,
+		},
+		Ports: PortsConfig{
+			Start: 3100,
+			Block: 10,
+		},
 	}
 }
 
@@ -68,6 +270,9 @@ func Load() (*Config, error) {
 
 	// Expand ~ in paths
 	cfg.WorktreeRoot = expandPath(cfg.WorktreeRoot)
+	for i, root := range cfg.Import.Roots {
+		cfg.Import.Roots[i] = expandPath(root)
+	}
 
 	return cfg, nil
 }
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestDecodeHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		want    Hook
+		wantErr bool
+	}{
+		{"command", `post_create = "npm install"`, Hook{Commands: []string{"npm install"}}, false},
+		{"empty", `post_create = ""`, Hook{}, false},
+		{"list", `post_create = ["go vet ./...", "go test ./..."]`, Hook{Commands: []string{"go vet ./...", "go test ./..."}}, false},
+		{"table", `post_create = { commands = ["make"], timeout = "30s", on_failure = "warn" }`,
+			Hook{Commands: []string{"make"}, Timeout: 30 * time.Second, OnFailure: HookWarn}, false},
+		{"table with one command", `post_create = { commands = "make" }`, Hook{Commands: []string{"make"}}, false},
+		{"bad timeout", `post_create = { commands = ["make"], timeout = "soon" }`, Hook{}, true},
+		{"bad failure mode", `post_create = { commands = ["make"], on_failure = "ignore" }`, Hook{}, true},
+		{"unknown setting", `post_create = { command = "make" }`, Hook{}, true},
+		{"not a command", `post_create = 3`, Hook{}, true},
+	}
+
+	for _, tt := range tests {
+		var hooks HooksConfig
+		_, err := toml.Decode(tt.toml, &hooks)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(hooks.PostCreate, tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, hooks.PostCreate)
+		}
+	}
+}
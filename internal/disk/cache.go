@@ -0,0 +1,130 @@
+package disk
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/emaland/ccs/internal/git"
+	"github.com/emaland/ccs/internal/state"
+)
+
+// modTimeDepth is how many directory levels below a worktree's root are
+// checked for changes. Installing dependencies or building touches
+// directories near the root, and checking deeper costs as much as measuring.
+const modTimeDepth = 2
+
+// Cache keeps worktree sizes between runs. An entry is used until the
+// worktree, a directory near its root or its git index is modified.
+type Cache struct {
+	path    string
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+type cacheEntry struct {
+	Usage    Usage     `json:"usage"`
+	ModTime  time.Time `json:"mod_time"`
+	Measured time.Time `json:"measured"`
+}
+
+// OpenCache loads the cache kept at path. A missing or unreadable cache is
+// treated as empty.
+func OpenCache(path string) *Cache {
+	c := &Cache{path: path, entries: map[string]cacheEntry{}}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &c.entries)
+	}
+	return c
+}
+
+// OpenDefaultCache opens the cache of worktree sizes kept with global
+// state, which every command that reports sizes shares
+func OpenDefaultCache() *Cache {
+	dir, err := state.Dir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return OpenCache(filepath.Join(dir, "du-cache.json"))
+}
+
+// Usage returns a worktree's disk usage, measuring it if the cached value
+// is stale or refresh is set
+func (c *Cache) Usage(g git.Git, worktree string, refresh bool) (Usage, error) {
+	mtime := modTime(worktree, modTimeDepth)
+	if e, ok := c.entries[worktree]; ok && !refresh && e.ModTime.Equal(mtime) {
+		return e.Usage, nil
+	}
+
+	u, err := Measure(g, worktree)
+	if err != nil {
+		return u, err
+	}
+	c.entries[worktree] = cacheEntry{Usage: u, ModTime: mtime, Measured: time.Now()}
+	c.dirty = true
+	return u, nil
+}
+
+// Save writes the cache back if anything was measured, dropping worktrees
+// that no longer exist
+func (c *Cache) Save() error {
+	for path := range c.entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// modTime returns the latest modification time of root, the directories
+// up to depth levels below it and the worktree's git index
+func modTime(root string, depth int) time.Time {
+	var latest time.Time
+	later := func(path string) {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	if gitDir, err := git.GitDir(root); err == nil {
+		later(filepath.Join(gitDir, "index"))
+	}
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		later(dir)
+		if depth == 0 {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if e.IsDir() && !(dir == root && e.Name() == ".git") {
+				walk(filepath.Join(dir, e.Name()), depth-1)
+			}
+		}
+	}
+	walk(root, depth)
+	return latest
+}
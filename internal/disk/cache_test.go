@@ -0,0 +1,35 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestModTime(t *testing.T) {
+	root := t.TempDir()
+	deep := filepath.Join(root, "a", "b", "c")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, dir := range []string{deep, filepath.Dir(deep), filepath.Join(root, "a"), root} {
+		os.Chtimes(dir, old, old)
+	}
+	if got := modTime(root, 2); !got.Equal(old) {
+		t.Fatalf("expected %v, got %v", old, got)
+	}
+
+	// Changes deeper than the checked depth go unnoticed
+	newer := old.Add(time.Hour)
+	os.Chtimes(deep, newer, newer)
+	if got := modTime(root, 2); !got.Equal(old) {
+		t.Errorf("change below depth: expected %v, got %v", old, got)
+	}
+
+	os.Chtimes(filepath.Dir(deep), newer, newer)
+	if got := modTime(root, 2); !got.Equal(newer) {
+		t.Errorf("change within depth: expected %v, got %v", newer, got)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build darwin
+
+package disk
+
+import "golang.org/x/sys/unix"
+
+// cloneFile makes dst a copy-on-write clone of src, which fails unless both
+// are on the same APFS volume
+func cloneFile(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package disk
+
+import "errors"
+
+// cloneFile isn't supported here, so files are always copied
+func cloneFile(src, dst string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,84 @@
+package disk
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/emaland/ccs/internal/git"
+)
+
+// Usage is the disk space taken by a worktree's files, in bytes. Git's own
+// data is shared between worktrees and not included.
+type Usage struct {
+	Tracked   int64 `json:"tracked"`
+	Untracked int64 `json:"untracked"` // Untracked files that aren't ignored
+	Ignored   int64 `json:"ignored"`   // e.g. node_modules and build output
+}
+
+// Total returns the space taken by all of the worktree's files
+func (u Usage) Total() int64 {
+	return u.Tracked + u.Untracked + u.Ignored
+}
+
+// Measure walks a worktree and splits its size into tracked, untracked and
+// ignored files. Ignored files are whatever git doesn't list, so they are
+// never walked twice.
+func Measure(g git.Git, worktree string) (Usage, error) {
+	var u Usage
+	tracked, err := g.ListFiles(false)
+	if err != nil {
+		return u, err
+	}
+	untracked, err := g.ListFiles(true)
+	if err != nil {
+		return u, err
+	}
+	for _, f := range tracked {
+		u.Tracked += fileSize(filepath.Join(worktree, f))
+	}
+	for _, f := range untracked {
+		u.Untracked += fileSize(filepath.Join(worktree, f))
+	}
+
+	total := treeSize(worktree)
+	u.Ignored = total - u.Tracked - u.Untracked
+	if u.Ignored < 0 {
+		// Files changed while walking
+		u.Ignored = 0
+	}
+	return u, nil
+}
+
+// fileSize returns the size of a regular file, or 0 for anything else
+func fileSize(path string) int64 {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+// treeSize returns the size of the regular files under root, leaving out
+// its .git
+func treeSize(root string) int64 {
+	var size int64
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Name() == ".git" && filepath.Dir(path) == root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
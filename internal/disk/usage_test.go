@@ -0,0 +1,31 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTreeSize(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]int{
+		"a.txt":                   10,
+		"node_modules/x/index.js": 100,
+		".git/objects/big":        1000, // Shared git data isn't counted
+		"sub/.git":                5,    // Only the root's .git is skipped
+	}
+	for name, size := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Symlink("a.txt", filepath.Join(root, "link"))
+
+	if got := treeSize(root); got != 115 {
+		t.Errorf("expected 115, got %d", got)
+	}
+}
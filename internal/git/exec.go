@@ -2,12 +2,15 @@ package git
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ExecGit implements Git interface using the git CLI
@@ -35,6 +38,34 @@ func FindRepoRoot(path string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// GitDir returns the git directory for a worktree without invoking git.
+// For linked worktrees this is the per-worktree directory under the main
+// repository's .git/worktrees/.
+func GitDir(worktree string) (string, error) {
+	dotGit := filepath.Join(worktree, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir: ") {
+		return "", fmt.Errorf("unrecognized .git file in %s", worktree)
+	}
+	dir := strings.TrimPrefix(line, "gitdir: ")
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(worktree, dir)
+	}
+	return dir, nil
+}
+
 func (g *ExecGit) git(args ...string) *exec.Cmd {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = g.repoRoot
@@ -42,7 +73,14 @@ func (g *ExecGit) git(args ...string) *exec.Cmd {
 }
 
 func (g *ExecGit) gitOutput(args ...string) (string, error) {
+	return g.gitOutputEnv(nil, args...)
+}
+
+func (g *ExecGit) gitOutputEnv(env []string, args ...string) (string, error) {
 	cmd := g.git(args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -91,6 +129,11 @@ func parseWorktreeList(output string) []WorktreeInfo {
 			current.Branch = branch
 		} else if line == "bare" && current != nil {
 			current.Bare = true
+		} else if strings.HasPrefix(line, "prunable") && current != nil {
+			current.Prunable = strings.TrimSpace(strings.TrimPrefix(line, "prunable"))
+			if current.Prunable == "" {
+				current.Prunable = "prunable"
+			}
 		}
 	}
 
@@ -111,6 +154,26 @@ func (g *ExecGit) WorktreeRemove(path string, force bool) error {
 	return err
 }
 
+// WorktreePrune removes administrative data for worktrees whose
+// directories are gone
+func (g *ExecGit) WorktreePrune() error {
+	_, err := g.gitOutput("worktree", "prune")
+	return err
+}
+
+func (g *ExecGit) WorktreeMove(path, newPath string) error {
+	_, err := g.gitOutput("worktree", "move", path, newPath)
+	return err
+}
+
+// WorktreeRepair reconnects worktrees with the repository, e.g. after
+// either was moved. Worktrees git doesn't know the new location of must be
+// given as paths.
+func (g *ExecGit) WorktreeRepair(paths ...string) error {
+	_, err := g.gitOutput(append([]string{"worktree", "repair"}, paths...)...)
+	return err
+}
+
 func (g *ExecGit) BranchCreate(name, ref string) error {
 	_, err := g.gitOutput("branch", name, ref)
 	return err
@@ -125,6 +188,13 @@ func (g *ExecGit) BranchDelete(name string, force bool) error {
 	return err
 }
 
+// BranchRename renames a branch, along with its config section and any
+// worktree that has it checked out
+func (g *ExecGit) BranchRename(name, newName string) error {
+	_, err := g.gitOutput("branch", "-m", name, newName)
+	return err
+}
+
 func (g *ExecGit) BranchCurrent() (string, error) {
 	return g.gitOutput("rev-parse", "--abbrev-ref", "HEAD")
 }
@@ -134,6 +204,21 @@ func (g *ExecGit) BranchExists(name string) bool {
 	return err == nil
 }
 
+// BranchList returns the local branches whose names start with prefix
+func (g *ExecGit) BranchList(prefix string) ([]string, error) {
+	out, err := g.gitOutput("for-each-ref", "--format=%(refname:short)", "refs/heads/"+prefix)
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, prefix) {
+			branches = append(branches, line)
+		}
+	}
+	return branches, nil
+}
+
 func (g *ExecGit) ResolveRef(ref string) (string, error) {
 	return g.gitOutput("rev-parse", ref)
 }
@@ -142,6 +227,23 @@ func (g *ExecGit) MergeBase(ref1, ref2 string) (string, error) {
 	return g.gitOutput("merge-base", ref1, ref2)
 }
 
+func (g *ExecGit) IsAncestor(ancestor, descendant string) (bool, error) {
+	cmd := g.git("merge-base", "--is-ancestor", ancestor, descendant)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+
+	// Exit status 1 means not an ancestor; anything else is a real error
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("git merge-base --is-ancestor: %w\n%s", err, stderr.String())
+}
+
 func (g *ExecGit) DiffStat(base, head string) (*DiffStat, error) {
 	out, err := g.gitOutput("diff", "--shortstat", base+".."+head)
 	if err != nil {
@@ -221,6 +323,34 @@ func (g *ExecGit) IsClean() (bool, error) {
 	return out == "", nil
 }
 
+func (g *ExecGit) HasTrackedChanges() (bool, error) {
+	out, err := g.gitOutput("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// ListFiles returns the tracked files, or with untracked set the untracked
+// files that aren't ignored, relative to the worktree root
+func (g *ExecGit) ListFiles(untracked bool) ([]string, error) {
+	args := []string{"ls-files", "-z"}
+	if untracked {
+		args = append(args, "--others", "--exclude-standard")
+	}
+	out, err := g.gitOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
 func (g *ExecGit) Log(base, head string, args ...string) (string, error) {
 	cmdArgs := []string{"log"}
 	cmdArgs = append(cmdArgs, args...)
@@ -243,11 +373,209 @@ func (g *ExecGit) Merge(branch string, ff bool) error {
 	return err
 }
 
+// MergeAutostash merges branch into the current branch like Merge, but
+// stashes uncommitted changes and reapplies them around the merge
+func (g *ExecGit) MergeAutostash(branch string) error {
+	_, err := g.gitOutput("merge", "--autostash", branch)
+	return err
+}
+
 func (g *ExecGit) Commit(message string) error {
 	_, err := g.gitOutput("commit", "-m", message)
 	return err
 }
 
+func (g *ExecGit) MergeFFOnly(ref string) error {
+	_, err := g.gitOutput("merge", "--ff-only", ref)
+	return err
+}
+
+// Rebase rebases the current branch onto upstream. Uncommitted changes are
+// stashed and reapplied around the rebase.
+func (g *ExecGit) Rebase(upstream string) error {
+	_, err := g.gitOutput("rebase", "--autostash", upstream)
+	return err
+}
+
+// RebaseOnto replays the commits after upstream onto onto, leaving out
+// upstream's own history. Used to move a branch stacked on another one after
+// that one was rewritten.
+func (g *ExecGit) RebaseOnto(onto, upstream string) error {
+	_, err := g.gitOutput("rebase", "--autostash", "--onto", onto, upstream)
+	return err
+}
+
+func (g *ExecGit) RebaseAbort() error {
+	_, err := g.gitOutput("rebase", "--abort")
+	return err
+}
+
+func (g *ExecGit) MergeAbort() error {
+	_, err := g.gitOutput("merge", "--abort")
+	return err
+}
+
+func (g *ExecGit) ConflictedFiles() ([]string, error) {
+	out, err := g.gitOutput("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// Snapshot commits the working tree, including untracked files that aren't
+// ignored, on top of HEAD and returns the commit. The index, working tree
+// and refs are left alone, so nothing references the commit.
+func (g *ExecGit) Snapshot(message string) (string, error) {
+	tmp, err := os.MkdirTemp("", "ccs-snapshot-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	// Start from a copy of the real index so unchanged files aren't rehashed
+	index := filepath.Join(tmp, "index")
+	realIndex, err := g.gitOutput("rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(realIndex) {
+		realIndex = filepath.Join(g.repoRoot, realIndex)
+	}
+	if data, err := os.ReadFile(realIndex); err == nil {
+		if err := os.WriteFile(index, data, 0644); err != nil {
+			return "", err
+		}
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + index}
+	if _, err := g.gitOutputEnv(env, "add", "--all"); err != nil {
+		return "", err
+	}
+	tree, err := g.gitOutputEnv(env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return g.CommitTree(tree, []string{"HEAD"}, message)
+}
+
+// RestoreWorktree makes the working tree match source, leaving the index
+// and HEAD alone so the differences show up as uncommitted changes
+func (g *ExecGit) RestoreWorktree(source string) error {
+	_, err := g.gitOutput("restore", "--source="+source, "--worktree", "--", ".")
+	return err
+}
+
+func (g *ExecGit) ResetHard(ref string) error {
+	_, err := g.gitOutput("reset", "--hard", "--quiet", ref)
+	return err
+}
+
+// CleanUntracked removes untracked files and directories, keeping ignored
+// ones such as build output and dependencies
+func (g *ExecGit) CleanUntracked() error {
+	_, err := g.gitOutput("clean", "-d", "--force", "--quiet")
+	return err
+}
+
+// MergeTree performs a merge of head into base without touching any
+// worktree or ref, returning the resulting tree. If the merge conflicts,
+// the conflicting paths are returned and tree is empty.
+func (g *ExecGit) MergeTree(base, head string) (string, []string, error) {
+	cmd := g.git("merge-tree", "--write-tree", "--name-only", "--no-messages", base, head)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	// Exit status 1 means the merge had conflicts
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", nil, fmt.Errorf("git merge-tree: %w\n%s", err, stderr.String())
+	}
+
+	tree, conflicts := parseMergeTree(stdout.String())
+	if err != nil {
+		return "", conflicts, nil
+	}
+	return tree, nil, nil
+}
+
+func parseMergeTree(output string) (string, []string) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	tree := lines[0]
+
+	var conflicts []string
+	for _, line := range lines[1:] {
+		if line == "" {
+			break // Informational messages follow a blank line
+		}
+		conflicts = append(conflicts, line)
+	}
+	return tree, conflicts
+}
+
+func (g *ExecGit) CommitTree(tree string, parents []string, message string) (string, error) {
+	args := []string{"commit-tree", tree}
+	for _, p := range parents {
+		args = append(args, "-p", p)
+	}
+	args = append(args, "-m", message)
+	return g.gitOutput(args...)
+}
+
+func (g *ExecGit) UpdateRef(ref, newValue, oldValue string) error {
+	args := []string{"update-ref", ref, newValue}
+	if oldValue != "" {
+		args = append(args, oldValue)
+	}
+	_, err := g.gitOutput(args...)
+	return err
+}
+
+func (g *ExecGit) DeleteRef(ref string) error {
+	_, err := g.gitOutput("update-ref", "-d", ref)
+	return err
+}
+
+// RefList returns the refs under prefix (e.g. "refs/heads/") that point to
+// commits
+func (g *ExecGit) RefList(prefix string) ([]RefInfo, error) {
+	out, err := g.gitOutput("for-each-ref", "--format=%(refname)%00%(objectname)%00%(creatordate:unix)%00%(subject)", prefix)
+	if err != nil {
+		return nil, err
+	}
+	return parseRefList(out), nil
+}
+
+func parseRefList(output string) []RefInfo {
+	var refs []RefInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		ref := RefInfo{Name: fields[0], Commit: fields[1], Subject: fields[3]}
+		if secs, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			ref.Time = time.Unix(secs, 0)
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+func (g *ExecGit) Fetch() error {
+	_, err := g.gitOutput("fetch", "--all", "--quiet")
+	return err
+}
+
+func (g *ExecGit) Upstream(branch string) (string, error) {
+	return g.gitOutput("rev-parse", "--abbrev-ref", branch+"@{upstream}")
+}
+
 func (g *ExecGit) Push(branch string, force bool) error {
 	args := []string{"push", "-u", "origin"}
 	if force {
@@ -262,6 +590,49 @@ func (g *ExecGit) RemoteURL(name string) (string, error) {
 	return g.gitOutput("remote", "get-url", name)
 }
 
+func (g *ExecGit) ConfigGet(key string) (string, error) {
+	return g.gitOutput("config", "--get", key)
+}
+
+func (g *ExecGit) ConfigSet(key, value string) error {
+	_, err := g.gitOutput("config", key, value)
+	return err
+}
+
+// ConfigGetRegexp returns all config entries whose key matches pattern.
+// Git lowercases section and variable names in keys, but not subsections.
+func (g *ExecGit) ConfigGetRegexp(pattern string) (map[string]string, error) {
+	cmd := g.git("config", "--null", "--get-regexp", pattern)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	// Exit status 1 means no matching keys
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git config --get-regexp: %w\n%s", err, stderr.String())
+	}
+	return parseConfigNull(stdout.String()), nil
+}
+
+// parseConfigNull parses "git config --null" output, where each entry is
+// the key and value separated by a newline and terminated by NUL
+func parseConfigNull(output string) map[string]string {
+	entries := map[string]string{}
+	for _, entry := range strings.Split(output, "\x00") {
+		if entry == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(entry, "\n")
+		entries[key] = value
+	}
+	return entries
+}
+
 func (g *ExecGit) InWorktree(path string) Git {
 	return &ExecGit{repoRoot: path}
 }
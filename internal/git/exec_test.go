@@ -3,6 +3,7 @@ package git
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParseWorktreeList(t *testing.T) {
@@ -17,11 +18,17 @@ branch refs/heads/ccs/feature
 worktree /Users/test/.ccs/repo/bugfix
 HEAD 123456789abc
 branch refs/heads/ccs/bugfix
+
+worktree /Users/test/.ccs/repo/gone
+HEAD 0123456789ab
+branch refs/heads/ccs/gone
+prunable gitdir file points to non-existent location
 `
 	expected := []WorktreeInfo{
 		{Path: "/Users/test/repo", HEAD: "abc123def456", Branch: "main"},
 		{Path: "/Users/test/.ccs/repo/feature", HEAD: "def789abc012", Branch: "ccs/feature"},
 		{Path: "/Users/test/.ccs/repo/bugfix", HEAD: "123456789abc", Branch: "ccs/bugfix"},
+		{Path: "/Users/test/.ccs/repo/gone", HEAD: "0123456789ab", Branch: "ccs/gone", Prunable: "gitdir file points to non-existent location"},
 	}
 
 	result := parseWorktreeList(input)
@@ -40,6 +47,9 @@ branch refs/heads/ccs/bugfix
 		if wt.Branch != expected[i].Branch {
 			t.Errorf("worktree %d: expected branch %q, got %q", i, expected[i].Branch, wt.Branch)
 		}
+		if wt.Prunable != expected[i].Prunable {
+			t.Errorf("worktree %d: expected prunable %q, got %q", i, expected[i].Prunable, wt.Prunable)
+		}
 	}
 }
 
@@ -103,3 +113,62 @@ R100	src/renamed.go
 		t.Errorf("expected %v, got %v", expected, result)
 	}
 }
+
+func TestParseMergeTree(t *testing.T) {
+	tests := []struct {
+		input     string
+		tree      string
+		conflicts []string
+	}{
+		{
+			input: "4b825dc642cb6eb9a060e54bf8d69288fbee4904\n",
+			tree:  "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+		},
+		{
+			input:     "4b825dc642cb6eb9a060e54bf8d69288fbee4904\nsrc/main.go\nREADME.md\n\nAuto-merging src/main.go\n",
+			tree:      "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+			conflicts: []string{"src/main.go", "README.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		tree, conflicts := parseMergeTree(tt.input)
+		if tree != tt.tree {
+			t.Errorf("input %q: expected tree %q, got %q", tt.input, tt.tree, tree)
+		}
+		if !reflect.DeepEqual(conflicts, tt.conflicts) {
+			t.Errorf("input %q: expected conflicts %v, got %v", tt.input, tt.conflicts, conflicts)
+		}
+	}
+}
+
+func TestParseConfigNull(t *testing.T) {
+	input := "branch.ccs/Feature.ccsbase\ndevelop\x00branch.ccs/Feature.ccsbasecommit\nabc123\x00core.bare\x00"
+	expected := map[string]string{
+		"branch.ccs/Feature.ccsbase":       "develop",
+		"branch.ccs/Feature.ccsbasecommit": "abc123",
+		"core.bare":                        "",
+	}
+
+	result := parseConfigNull(input)
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestParseRefList(t *testing.T) {
+	input := "refs/ccs/checkpoints/auth/1\x00abc123\x001750000000\x00ccs checkpoint: before refactor\n" +
+		"refs/ccs/checkpoints/auth/2\x00def456\x00bad\x00ccs auto-checkpoint\n" +
+		"garbage"
+	expected := []RefInfo{
+		{Name: "refs/ccs/checkpoints/auth/1", Commit: "abc123", Subject: "ccs checkpoint: before refactor", Time: time.Unix(1750000000, 0)},
+		{Name: "refs/ccs/checkpoints/auth/2", Commit: "def456", Subject: "ccs auto-checkpoint"},
+	}
+
+	result := parseRefList(input)
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
@@ -1,5 +1,7 @@
 package git
 
+import "time"
+
 // FileStatus represents the status of a file in git
 type FileStatus string
 
@@ -13,10 +15,19 @@ const (
 
 // WorktreeInfo contains information about a git worktree
 type WorktreeInfo struct {
-	Path   string
-	Branch string
-	HEAD   string
-	Bare   bool
+	Path     string
+	Branch   string
+	HEAD     string
+	Bare     bool
+	Prunable string // Why git considers the worktree prunable, if it does
+}
+
+// RefInfo describes a ref and the commit it points to
+type RefInfo struct {
+	Name    string
+	Commit  string
+	Subject string
+	Time    time.Time // Commit time
 }
 
 // DiffStat contains diff statistics
@@ -44,16 +55,22 @@ type Git interface {
 	WorktreeAdd(path, branch, base string) error
 	WorktreeList() ([]WorktreeInfo, error)
 	WorktreeRemove(path string, force bool) error
+	WorktreeMove(path, newPath string) error
+	WorktreePrune() error
+	WorktreeRepair(paths ...string) error
 
 	// Branch operations
 	BranchCreate(name, ref string) error
 	BranchDelete(name string, force bool) error
+	BranchRename(name, newName string) error
 	BranchCurrent() (string, error)
 	BranchExists(name string) bool
+	BranchList(prefix string) ([]string, error)
 
 	// Ref operations
 	ResolveRef(ref string) (string, error)
 	MergeBase(ref1, ref2 string) (string, error)
+	IsAncestor(ancestor, descendant string) (bool, error)
 
 	// Diff and status
 	DiffStat(base, head string) (*DiffStat, error)
@@ -61,6 +78,8 @@ type Git interface {
 	DiffRaw(base, head string, args ...string) (string, error)
 	CommitCount(base, head string) (int, error)
 	IsClean() (bool, error)
+	HasTrackedChanges() (bool, error)
+	ListFiles(untracked bool) ([]string, error)
 
 	// Log
 	Log(base, head string, args ...string) (string, error)
@@ -68,12 +87,39 @@ type Git interface {
 	// Commit operations
 	MergeSquash(branch string) error
 	Merge(branch string, ff bool) error
+	MergeAutostash(branch string) error
 	Commit(message string) error
+	MergeFFOnly(ref string) error
+	Rebase(upstream string) error
+	RebaseOnto(onto, upstream string) error
+	RebaseAbort() error
+	MergeAbort() error
+	ConflictedFiles() ([]string, error)
+
+	// Snapshots of uncommitted work
+	Snapshot(message string) (string, error)
+	RestoreWorktree(source string) error
+	ResetHard(ref string) error
+	CleanUntracked() error
+
+	// Plumbing for merging without a checkout
+	MergeTree(base, head string) (tree string, conflicts []string, err error)
+	CommitTree(tree string, parents []string, message string) (string, error)
+	UpdateRef(ref, newValue, oldValue string) error
+	DeleteRef(ref string) error
+	RefList(prefix string) ([]RefInfo, error)
 
 	// Remote
+	Fetch() error
+	Upstream(branch string) (string, error)
 	Push(branch string, force bool) error
 	RemoteURL(name string) (string, error)
 
+	// Config
+	ConfigGet(key string) (string, error)
+	ConfigSet(key, value string) error
+	ConfigGetRegexp(pattern string) (map[string]string, error)
+
 	// Working in different directories
 	InWorktree(path string) Git
 }
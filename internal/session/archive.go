@@ -0,0 +1,223 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/state"
+)
+
+// Archived branches are kept under refs/ccs/archive/<session>, outside
+// refs/heads so they don't clutter branch lists
+const archiveRefPrefix = "refs/ccs/archive/"
+
+// archiveCheckpointLabel labels the checkpoint holding the uncommitted
+// changes of a session when it was archived
+const archiveCheckpointLabel = "archived"
+
+// RestoreOptions contains options for restoring an archived session
+type RestoreOptions struct {
+	Continue   bool     // Start Claude with --continue
+	NoTerminal bool     // Don't create terminal window
+	ClaudeArgs []string // Arguments to pass to Claude
+}
+
+// Archive frees a session's worktree and branch while keeping its work:
+// the branch is saved under refs/ccs/archive/, uncommitted changes as a
+// checkpoint, and the session's details in global state, so Restore can
+// bring it back as it was. Sessions stacked on it are moved onto its base
+// if force is set, as with Delete.
+func (m *Manager) Archive(name string, force bool) (*Session, error) {
+	if m.state == nil {
+		return nil, fmt.Errorf("archiving needs global state")
+	}
+	session, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	// Anything Claude writes after the checkpoint would be lost with the
+	// worktree
+	if err := m.checkClaudeStopped(session); err != nil {
+		return nil, err
+	}
+	if force {
+		err = m.restackChildren(session)
+	} else {
+		err = m.checkNoChildren(session)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ref := archiveRefPrefix + name
+	if _, err := m.git.ResolveRef(ref); err == nil {
+		return nil, fmt.Errorf("an archived session named %s already exists (%s)", name, ref)
+	}
+
+	wtGit := m.git.InWorktree(session.Path)
+	head, err := wtGit.ResolveRef("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if clean, err := wtGit.IsClean(); err != nil {
+		return nil, err
+	} else if !clean {
+		if _, err := m.Checkpoint(session, archiveCheckpointLabel, false); err != nil {
+			return nil, fmt.Errorf("could not save uncommitted changes: %w", err)
+		}
+	}
+	if err := m.git.UpdateRef(ref, head, ""); err != nil {
+		return nil, err
+	}
+
+	entry := state.SessionState{CreatedAt: time.Now()}
+	if st := m.state.GetSession(session.Path); st != nil {
+		entry = *st
+	}
+	entry.Name = session.Name
+	entry.RepoPath = m.git.RepoRoot()
+	entry.RepoName = m.git.RepoName()
+	entry.WorkTree = session.Path
+	entry.Branch = session.Branch
+	entry.BaseBranch = session.BaseBranch
+	entry.BaseCommit = session.BaseCommit
+	entry.Parent = session.Parent
+	entry.LastAccess = time.Now()
+	entry.Archived = true
+	entry.ArchiveRef = ref
+	entry.Transcripts = claude.ProjectDir(session.Path)
+	entry.PortBase = 0
+	entry.PortCount = 0
+	if err := m.state.AddSession(entry); err != nil {
+		m.git.DeleteRef(ref)
+		return nil, err
+	}
+
+	// Everything is saved, so the worktree can go even if it is dirty
+	if err := m.removeWorktree(session, true, true); err != nil {
+		return nil, err
+	}
+
+	m.recordEvent(session, state.EventArchived, "", head)
+	return session, nil
+}
+
+// Archived returns the repository's archived sessions
+func (m *Manager) Archived() []state.SessionState {
+	if m.state == nil {
+		return nil
+	}
+	var archived []state.SessionState
+	for _, s := range m.state.GetSessionsForRepo(m.git.RepoRoot()) {
+		if s.Archived {
+			archived = append(archived, s)
+		}
+	}
+	return archived
+}
+
+// Restore recreates an archived session's branch and worktree in the place
+// they were, with any uncommitted changes, and opens a terminal window.
+// Claude's transcripts were left in place, so --continue picks up the
+// conversation.
+func (m *Manager) Restore(name string, opts RestoreOptions) (*Session, error) {
+	entry := m.archivedEntry(name)
+	if entry == nil {
+		return nil, fmt.Errorf("no archived session named %s\n\nUse 'ccs archive ls' to list them", name)
+	}
+	if m.git.BranchExists(entry.Branch) {
+		return nil, fmt.Errorf("branch %s already exists", entry.Branch)
+	}
+	if _, err := os.Lstat(entry.WorkTree); err == nil {
+		return nil, fmt.Errorf("%s already exists", entry.WorkTree)
+	}
+
+	head, err := m.git.ResolveRef(entry.ArchiveRef)
+	if err != nil {
+		return nil, fmt.Errorf("archived branch %s is missing: %w", entry.ArchiveRef, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry.WorkTree), 0755); err != nil {
+		return nil, fmt.Errorf("could not create parent directory: %w", err)
+	}
+	if err := m.git.WorktreeAdd(entry.WorkTree, entry.Branch, head); err != nil {
+		return nil, fmt.Errorf("could not create worktree: %w", err)
+	}
+	m.git.ConfigSet(baseConfigKey(entry.Branch), entry.BaseBranch)
+	if entry.BaseCommit != "" {
+		m.git.ConfigSet(baseCommitConfigKey(entry.Branch), entry.BaseCommit)
+	}
+
+	// Ignored files weren't archived, so bring them in as for a new session
+	if err := m.copyLocalFiles(entry.WorkTree); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	session := &Session{
+		Name:       entry.Name,
+		Path:       entry.WorkTree,
+		Branch:     entry.Branch,
+		BaseBranch: entry.BaseBranch,
+		BaseCommit: entry.BaseCommit,
+		Parent:     entry.Parent,
+		RepoRoot:   m.git.RepoRoot(),
+	}
+
+	// Put back uncommitted changes saved when the session was archived
+	if checkpoints, err := m.Checkpoints(session); err == nil && len(checkpoints) > 0 {
+		last := checkpoints[len(checkpoints)-1]
+		if parent, err := m.git.ResolveRef(last.Commit + "^"); err == nil && last.Label == archiveCheckpointLabel && parent == head {
+			if err := m.git.InWorktree(session.Path).RestoreWorktree(last.Commit); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not restore uncommitted changes (checkpoint %d): %v\n", last.Number, err)
+			}
+		}
+	}
+
+	m.git.DeleteRef(entry.ArchiveRef)
+	entry.Archived = false
+	entry.ArchiveRef = ""
+	entry.Transcripts = ""
+	entry.LastAccess = time.Now()
+	if err := m.state.AddSession(*entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save state: %v\n", err)
+	}
+
+	if err := m.writeEnvFile(session); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write %s/env: %v\n", envDir, err)
+	}
+
+	m.recordEvent(session, state.EventRestored, "", head)
+
+	if !opts.NoTerminal && m.terminal.Name() != "none" {
+		startCmd := ""
+		if opts.Continue {
+			startCmd = strings.Join(append([]string{"claude", "--continue"}, opts.ClaudeArgs...), " ")
+		}
+		if err := m.createWindow(session, startCmd); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not create terminal window: %v\n", err)
+		}
+	}
+
+	return session, nil
+}
+
+// checkNotArchived returns an error if name is taken by an archived session
+func (m *Manager) checkNotArchived(name string) error {
+	if m.archivedEntry(name) != nil {
+		return fmt.Errorf("session %q is archived\n\nUse 'ccs restore %s' to bring it back", name, name)
+	}
+	return nil
+}
+
+func (m *Manager) archivedEntry(name string) *state.SessionState {
+	for _, s := range m.Archived() {
+		if s.Name == name {
+			return &s
+		}
+	}
+	return nil
+}
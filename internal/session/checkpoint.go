@@ -0,0 +1,210 @@
+package session
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Checkpoints are snapshot commits of a worktree, including uncommitted and
+// untracked files, kept under refs/ccs/checkpoints/<session>/<n>. The
+// snapshot's parent is the HEAD at the time, so nothing on the branch moves.
+const checkpointRefPrefix = "refs/ccs/checkpoints/"
+
+const (
+	checkpointSubject     = "ccs checkpoint"
+	autoCheckpointSubject = "ccs auto-checkpoint"
+)
+
+// Checkpoint is a saved state of a session's worktree
+type Checkpoint struct {
+	Number int       `json:"number"`
+	Label  string    `json:"label,omitempty"`
+	Auto   bool      `json:"auto"` // Taken when Claude stopped
+	Ref    string    `json:"ref"`
+	Commit string    `json:"commit"`
+	Time   time.Time `json:"time"`
+}
+
+// Name is the label of a checkpoint, or its number if it has none
+func (c *Checkpoint) Name() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return "#" + strconv.Itoa(c.Number)
+}
+
+func checkpointRefs(sessionName string) string {
+	return checkpointRefPrefix + sessionName + "/"
+}
+
+// Checkpoints returns a session's checkpoints, oldest first
+func (m *Manager) Checkpoints(session *Session) ([]*Checkpoint, error) {
+	refs, err := m.git.RefList(checkpointRefs(session.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoints []*Checkpoint
+	for _, ref := range refs {
+		n, err := strconv.Atoi(path.Base(ref.Name))
+		if err != nil {
+			continue
+		}
+		cp := &Checkpoint{Number: n, Ref: ref.Name, Commit: ref.Commit, Time: ref.Time}
+		switch {
+		case ref.Subject == autoCheckpointSubject:
+			cp.Auto = true
+		case strings.HasPrefix(ref.Subject, checkpointSubject+": "):
+			cp.Label = strings.TrimPrefix(ref.Subject, checkpointSubject+": ")
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Number < checkpoints[j].Number
+	})
+	return checkpoints, nil
+}
+
+// FindCheckpoint finds a session's checkpoint by number, or the most
+// recent one with a label
+func (m *Manager) FindCheckpoint(session *Session, name string) (*Checkpoint, error) {
+	checkpoints, err := m.Checkpoints(session)
+	if err != nil {
+		return nil, err
+	}
+	n, numErr := strconv.Atoi(strings.TrimPrefix(name, "#"))
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		cp := checkpoints[i]
+		if cp.Label == name || (numErr == nil && cp.Number == n) {
+			return cp, nil
+		}
+	}
+	return nil, fmt.Errorf("no checkpoint %q in %s\n\nUse 'ccs checkpoint ls %s' to list them", name, session.Name, session.Name)
+}
+
+// Checkpoint saves the current state of a session's worktree. Automatic
+// checkpoints are skipped when nothing changed since the last checkpoint,
+// returning nil, and only the most recent [checkpoint] keep are kept.
+func (m *Manager) Checkpoint(session *Session, label string, auto bool) (*Checkpoint, error) {
+	wtGit := m.git.InWorktree(session.Path)
+
+	subject := checkpointSubject
+	if auto {
+		subject = autoCheckpointSubject
+	} else if label != "" {
+		subject += ": " + label
+	}
+	commit, err := wtGit.Snapshot(subject)
+	if err != nil {
+		return nil, fmt.Errorf("could not snapshot %s: %w", session.Name, err)
+	}
+
+	checkpoints, err := m.Checkpoints(session)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if len(checkpoints) > 0 {
+		last := checkpoints[len(checkpoints)-1]
+		next = last.Number + 1
+		if auto && m.sameTree(last.Commit, commit) {
+			return nil, nil
+		}
+	}
+
+	cp := &Checkpoint{
+		Number: next,
+		Label:  label,
+		Auto:   auto,
+		Ref:    checkpointRefs(session.Name) + strconv.Itoa(next),
+		Commit: commit,
+		Time:   time.Now(),
+	}
+	if err := m.git.UpdateRef(cp.Ref, commit, ""); err != nil {
+		return nil, err
+	}
+
+	if auto && m.cfg.Checkpoint.Keep > 0 {
+		var autos []*Checkpoint
+		for _, c := range checkpoints {
+			if c.Auto {
+				autos = append(autos, c)
+			}
+		}
+		// The new checkpoint counts towards the limit
+		for len(autos) >= m.cfg.Checkpoint.Keep {
+			m.git.DeleteRef(autos[0].Ref)
+			autos = autos[1:]
+		}
+	}
+	return cp, nil
+}
+
+// Rollback restores a session's worktree to a checkpoint: the branch is
+// reset to the commit it was on and the uncommitted and untracked files
+// are put back, while anything not in the checkpoint is removed. The state
+// before the rollback is checkpointed first and returned, so a rollback can
+// itself be undone.
+func (m *Manager) Rollback(session *Session, cp *Checkpoint) (*Checkpoint, error) {
+	// Claude would keep working against files changed under it, and its
+	// writes could land after the checkpoint taken here
+	if err := m.checkClaudeStopped(session); err != nil {
+		return nil, err
+	}
+
+	saved, err := m.Checkpoint(session, "before rollback to "+cp.Name(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	wtGit := m.git.InWorktree(session.Path)
+	head, err := wtGit.ResolveRef(cp.Commit + "^")
+	if err != nil {
+		return saved, err
+	}
+	if err := wtGit.ResetHard(head); err != nil {
+		return saved, err
+	}
+	if err := wtGit.CleanUntracked(); err != nil {
+		return saved, err
+	}
+	if err := wtGit.RestoreWorktree(cp.Commit); err != nil {
+		return saved, err
+	}
+	return saved, nil
+}
+
+// sameTree reports whether two commits have identical content
+func (m *Manager) sameTree(a, b string) bool {
+	treeA, errA := m.git.ResolveRef(a + "^{tree}")
+	treeB, errB := m.git.ResolveRef(b + "^{tree}")
+	return errA == nil && errB == nil && treeA == treeB
+}
+
+// moveCheckpoints moves a session's checkpoints when it is renamed
+func (m *Manager) moveCheckpoints(oldName, newName string) error {
+	refs, err := m.git.RefList(checkpointRefs(oldName))
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		newRef := checkpointRefs(newName) + path.Base(ref.Name)
+		if err := m.git.UpdateRef(newRef, ref.Commit, ""); err != nil {
+			return err
+		}
+		m.git.DeleteRef(ref.Name)
+	}
+	return nil
+}
+
+// deleteCheckpoints removes a session's checkpoints
+func (m *Manager) deleteCheckpoints(sessionName string) {
+	refs, _ := m.git.RefList(checkpointRefs(sessionName))
+	for _, ref := range refs {
+		m.git.DeleteRef(ref.Name)
+	}
+}
@@ -0,0 +1,164 @@
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Comparison is how one session fared in a comparison
+type Comparison struct {
+	Name         string       `json:"name"`
+	Branch       string       `json:"branch"`
+	Commits      int          `json:"commits"`
+	FilesChanged int          `json:"files_changed"`
+	Insertions   int          `json:"insertions"`
+	Deletions    int          `json:"deletions"`
+	Uncommitted  bool         `json:"uncommitted"` // Working tree has changes the diffs don't include
+	Tests        []TestResult `json:"tests,omitempty"`
+	TokensIn     int          `json:"tokens_in"`
+	TokensOut    int          `json:"tokens_out"`
+	Cost         float64      `json:"cost"`
+}
+
+// TestResult is the outcome of running a test command in a session
+type TestResult struct {
+	Command string  `json:"command"`
+	Passed  bool    `json:"passed"`
+	Seconds float64 `json:"seconds"`
+	Output  string  `json:"output,omitempty"` // End of the output, for failures
+}
+
+// PairDiff is the difference between two sessions' branches
+type PairDiff struct {
+	A            string `json:"a"`
+	B            string `json:"b"`
+	FilesChanged int    `json:"files_changed"`
+	Insertions   int    `json:"insertions"`
+	Deletions    int    `json:"deletions"`
+}
+
+// Passed returns how many of the session's tests passed
+func (c *Comparison) Passed() int {
+	n := 0
+	for _, t := range c.Tests {
+		if t.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// testOutputLines is how much of a failing test's output is kept
+const testOutputLines = 20
+
+// Compare gathers each session's changes, Claude usage and, if runTests is
+// set, the results of the configured test commands, and diffs every pair
+// of sessions. Sessions are returned best first: most tests passed, then
+// smallest change, then cheapest.
+func (m *Manager) Compare(names []string, runTests bool) ([]*Comparison, []PairDiff, error) {
+	var sessions []*Session
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			return nil, nil, fmt.Errorf("%s is listed more than once", name)
+		}
+		seen[name] = true
+
+		s, err := m.Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	var results []*Comparison
+	for _, s := range sessions {
+		c := &Comparison{Name: s.Name, Branch: s.Branch}
+		wtGit := m.git.InWorktree(s.Path)
+		mergeBase := m.MergeBase(s)
+
+		if stat, err := wtGit.DiffStat(mergeBase, "HEAD"); err == nil {
+			c.FilesChanged = stat.FilesChanged
+			c.Insertions = stat.Insertions
+			c.Deletions = stat.Deletions
+		}
+		c.Commits, _ = wtGit.CommitCount(mergeBase, "HEAD")
+		if clean, err := wtGit.IsClean(); err == nil {
+			c.Uncommitted = !clean
+		}
+
+		info := m.GetClaudeInfo(s)
+		c.TokensIn = info.TokensIn
+		c.TokensOut = info.TokensOut
+		c.Cost = info.Cost
+
+		if runTests {
+			for _, command := range m.cfg.Compare.Tests {
+				c.Tests = append(c.Tests, runTest(command, s.Path))
+			}
+		}
+		results = append(results, c)
+	}
+
+	var pairs []PairDiff
+	for i := range sessions {
+		for j := i + 1; j < len(sessions); j++ {
+			a, b := sessions[i], sessions[j]
+			pair := PairDiff{A: a.Name, B: b.Name}
+			if stat, err := m.git.DiffStat(a.Branch, b.Branch); err == nil {
+				pair.FilesChanged = stat.FilesChanged
+				pair.Insertions = stat.Insertions
+				pair.Deletions = stat.Deletions
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+
+	rankComparisons(results)
+	return results, pairs, nil
+}
+
+// rankComparisons sorts sessions best first: most tests passed, then
+// smallest change, then cheapest
+func rankComparisons(results []*Comparison) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Passed() != b.Passed() {
+			return a.Passed() > b.Passed()
+		}
+		if la, lb := a.Insertions+a.Deletions, b.Insertions+b.Deletions; la != lb {
+			return la < lb
+		}
+		return a.Cost < b.Cost
+	})
+}
+
+// runTest runs a test command in a worktree and keeps the end of its output
+// if it fails
+func runTest(command, dir string) TestResult {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	err := cmd.Run()
+	result := TestResult{
+		Command: command,
+		Passed:  err == nil,
+		Seconds: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+		if len(lines) > testOutputLines {
+			lines = lines[len(lines)-testOutputLines:]
+		}
+		result.Output = strings.Join(lines, "\n")
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// envDir is where a session's environment is written in its worktree,
+// ignored by a .gitignore of its own so it never shows up as a change
+const envDir = ".ccs"
+
+// sessionEnv returns the environment variables describing a session,
+// reserving its block of ports first if it has none:
+//
+//	CCS_SESSION, CCS_BRANCH, CCS_BASE, CCS_WORKTREE, CCS_REPO_ROOT
+//	CCS_PORT_BASE, CCS_PORT_COUNT and PORT, set to CCS_PORT_BASE
+func (m *Manager) sessionEnv(session *Session) []string {
+	env := []string{
+		"CCS_SESSION=" + session.Name,
+		"CCS_BRANCH=" + session.Branch,
+		"CCS_BASE=" + session.BaseBranch,
+		"CCS_WORKTREE=" + session.Path,
+		"CCS_REPO_ROOT=" + m.git.RepoRoot(),
+	}
+	if base, count := m.Ports(session); count > 0 {
+		env = append(env,
+			"CCS_PORT_BASE="+strconv.Itoa(base),
+			"CCS_PORT_COUNT="+strconv.Itoa(count),
+			"PORT="+strconv.Itoa(base),
+		)
+	}
+	return env
+}
+
+// writeEnvFile writes a session's environment to .ccs/env in its worktree,
+// for tools that load dotenv files and for 'source .ccs/env'
+func (m *Manager) writeEnvFile(session *Session) error {
+	dir := filepath.Join(session.Path, envDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0644); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, kv := range m.sessionEnv(session) {
+		key, value, _ := strings.Cut(kv, "=")
+		if !plainValue.MatchString(value) {
+			value = shellQuote(value)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, value)
+	}
+	return os.WriteFile(filepath.Join(dir, "env"), []byte(b.String()), 0644)
+}
+
+// plainValue matches values that need no quoting in a dotenv or shell file
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@+-]*$`)
+
+// createWindow opens a terminal window for a session with its environment
+// set, refreshing .ccs/env first
+func (m *Manager) createWindow(session *Session, startCmd string) error {
+	if err := m.writeEnvFile(session); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write %s/env: %v\n", envDir, err)
+	}
+	return m.terminal.CreateWindow(session.Name, session.Path, startCmd, m.sessionEnv(session))
+}
+
+// Ports returns a session's block of ports, reserving one in global state
+// if it has none. Ports are released when the session's state goes, on
+// delete, finish or archive.
+func (m *Manager) Ports(session *Session) (base, count int) {
+	if m.state == nil || m.cfg.Ports.Block <= 0 {
+		return 0, 0
+	}
+	base, count, err := m.state.ReservePorts(session.Path, m.cfg.Ports.Start, m.cfg.Ports.Block, portFree)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not reserve ports: %v\n", err)
+		return 0, 0
+	}
+	return base, count
+}
+
+// portFree reports whether a port can be listened on locally
+func portFree(port int) bool {
+	l, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
@@ -0,0 +1,386 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/state"
+)
+
+// FinishOptions contains options for finishing a session
+type FinishOptions struct {
+	Squash bool
+	Merge  bool
+	Rebase bool // Rebase onto base, then fast-forward base
+	FFOnly bool // Fast-forward base, failing if the branches diverged
+	PR     bool
+	Delete bool
+	Force  bool
+}
+
+// Strategy names the way the options finish a session
+func (o FinishOptions) Strategy() string {
+	switch {
+	case o.Delete:
+		return "delete"
+	case o.PR:
+		return "pr"
+	case o.Squash:
+		return "squash"
+	case o.Merge:
+		return "merge"
+	case o.Rebase:
+		return "rebase"
+	case o.FFOnly:
+		return "ff-only"
+	}
+	return ""
+}
+
+// FinishPreview describes what finishing a session would do
+type FinishPreview struct {
+	Base           string
+	Commits        int
+	FilesChanged   int
+	CanFastForward bool
+	Conflicts      []string
+	BaseWorktree   string // Worktree with the base checked out, if any
+	BaseDirty      bool   // BaseWorktree has uncommitted changes
+}
+
+// Finish finishes a session with the given options
+func (m *Manager) Finish(name string, opts FinishOptions) error {
+	session, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+
+	// Run pre-finish hook
+	if !opts.Force {
+		if err := m.runHook(HookPreFinish, session, opts.Strategy()); err != nil {
+			return fmt.Errorf("%w\nUse --force to skip", err)
+		}
+	}
+
+	var commit string
+	switch {
+	case opts.Delete:
+		if err := m.Delete(name, opts.Force); err != nil {
+			return err
+		}
+		return m.runHook(HookPostFinish, session, opts.Strategy())
+
+	case opts.PR:
+		commit, err = m.finishPR(session, opts.Force)
+
+	case opts.Squash:
+		commit, err = m.mergeSession(session, true, opts.Force)
+
+	case opts.Merge:
+		commit, err = m.mergeSession(session, false, opts.Force)
+
+	case opts.Rebase:
+		commit, err = m.rebaseSession(session, opts.Force)
+
+	case opts.FFOnly:
+		commit, err = m.fastForwardSession(session, opts.Force)
+
+	default:
+		return fmt.Errorf("no finish action specified")
+	}
+	if err != nil {
+		return err
+	}
+
+	m.recordEvent(session, state.EventFinished, opts.Strategy(), commit)
+	return m.runHook(HookPostFinish, session, opts.Strategy())
+}
+
+// finishPR pushes the session's branch, and the rest of its stack, for PRs.
+// The branch is kept but the worktree is removed.
+func (m *Manager) finishPR(session *Session, force bool) (string, error) {
+	stack, err := m.Stack(session.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := m.pushStack(stack); err != nil {
+		return "", err
+	}
+	if len(stack) > 1 {
+		fmt.Println("Create a PR for each branch against its base, bottom of the stack first.")
+	} else {
+		fmt.Println("Create a PR at your repository.")
+	}
+
+	// Stop claude process
+	claude.StopProcess(session.Path)
+
+	// Close terminal window
+	if m.terminal.Name() != "none" {
+		m.terminal.CloseWindow(session.Name)
+	}
+
+	// Remove worktree but keep branch
+	if err := m.git.WorktreeRemove(session.Path, force); err != nil {
+		return "", err
+	}
+	if m.state != nil {
+		m.state.RemoveSession(session.Path)
+	}
+	return m.git.ResolveRef(session.Branch)
+}
+
+// PreviewFinish reports whether a session can be merged into its base and
+// which files would conflict, without changing anything
+func (m *Manager) PreviewFinish(name string) (*FinishPreview, error) {
+	session, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &FinishPreview{Base: m.baseBranch(session)}
+
+	baseHead, err := m.git.ResolveRef("refs/heads/" + preview.Base)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve base branch %s: %w", preview.Base, err)
+	}
+	sessionHead, err := m.git.ResolveRef(session.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve session branch %s: %w", session.Branch, err)
+	}
+
+	mergeBase, err := m.git.MergeBase(baseHead, sessionHead)
+	if err != nil {
+		return nil, err
+	}
+	preview.Commits, _ = m.git.CommitCount(mergeBase, sessionHead)
+	if stat, err := m.git.DiffStat(mergeBase, sessionHead); err == nil {
+		preview.FilesChanged = stat.FilesChanged
+	}
+	preview.CanFastForward = mergeBase == baseHead
+
+	_, preview.Conflicts, err = m.git.MergeTree(baseHead, sessionHead)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.BaseWorktree, err = m.worktreeForBranch(preview.Base)
+	if err != nil {
+		return nil, err
+	}
+	if preview.BaseWorktree != "" {
+		preview.BaseDirty, _ = m.git.InWorktree(preview.BaseWorktree).HasTrackedChanges()
+	}
+
+	return preview, nil
+}
+
+// mergeSession merges a session into the base branch without checking the
+// base out. The merge is computed with git merge-tree and the base ref is
+// updated directly, so it works regardless of what the main worktree has
+// checked out.
+func (m *Manager) mergeSession(session *Session, squash, force bool) (string, error) {
+	base := m.baseBranch(session)
+
+	oldBase, sessionHead, baseWorktree, err := m.prepareFinish(session, base)
+	if err != nil {
+		return "", err
+	}
+
+	// Get commit count for message
+	mergeBase, _ := m.git.MergeBase(oldBase, sessionHead)
+	commitCount, _ := m.git.CommitCount(mergeBase, sessionHead)
+	if commitCount == 0 {
+		fmt.Printf("Nothing to merge from %s.\n", session.Name)
+		if err := m.restackChildren(session); err != nil {
+			return "", err
+		}
+		return "", m.deleteSession(session, force, false)
+	}
+
+	tree, conflicts, err := m.git.MergeTree(oldBase, sessionHead)
+	if err != nil {
+		return "", fmt.Errorf("merge failed: %w", err)
+	}
+	if len(conflicts) > 0 {
+		return "", fmt.Errorf("merging %s into %s conflicts in:\n  %s", session.Branch, base, strings.Join(conflicts, "\n  "))
+	}
+
+	var parents []string
+	var msg string
+	if squash {
+		parents = []string{oldBase}
+		msg = fmt.Sprintf("Squashed %d commits from %s", commitCount, session.Name)
+	} else {
+		parents = []string{oldBase, sessionHead}
+		msg = fmt.Sprintf("Merge branch '%s'", session.Branch)
+	}
+
+	commit, err := m.git.CommitTree(tree, parents, msg)
+	if err != nil {
+		return "", fmt.Errorf("commit failed: %w", err)
+	}
+
+	if err := m.advanceBase(base, baseWorktree, oldBase, commit); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Merged %s into %s (%.7s)\n", session.Name, base, commit)
+
+	// Cleanup
+	if err := m.restackChildren(session); err != nil {
+		return "", err
+	}
+	return commit, m.deleteSession(session, force, true)
+}
+
+// rebaseSession rebases the session branch onto the current base in the
+// session's worktree, then fast-forwards the base to the result
+func (m *Manager) rebaseSession(session *Session, force bool) (string, error) {
+	base := m.baseBranch(session)
+
+	oldBase, _, baseWorktree, err := m.prepareFinish(session, base)
+	if err != nil {
+		return "", err
+	}
+
+	// The rebase rewrites files Claude may be editing, which
+	// HasTrackedChanges can't see until they are written
+	if err := m.checkClaudeStopped(session); err != nil {
+		return "", err
+	}
+	wtGit := m.git.InWorktree(session.Path)
+	dirty, err := wtGit.HasTrackedChanges()
+	if err != nil {
+		return "", err
+	}
+	if dirty {
+		return "", fmt.Errorf("session %s has uncommitted changes\nCommit them before rebasing", session.Name)
+	}
+
+	if err := wtGit.Rebase(oldBase); err != nil {
+		wtGit.RebaseAbort()
+		_, conflicts, _ := m.git.MergeTree(oldBase, session.Branch)
+		if len(conflicts) > 0 {
+			return "", fmt.Errorf("rebasing %s onto %s conflicts in:\n  %s", session.Branch, base, strings.Join(conflicts, "\n  "))
+		}
+		return "", fmt.Errorf("rebase failed: %w", err)
+	}
+
+	newHead, err := m.git.ResolveRef(session.Branch)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.advanceBase(base, baseWorktree, oldBase, newHead); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Rebased %s onto %s and fast-forwarded (%.7s)\n", session.Name, base, newHead)
+
+	if err := m.restackChildren(session); err != nil {
+		return "", err
+	}
+	return newHead, m.deleteSession(session, force, true)
+}
+
+// fastForwardSession moves the base to the session head, which must
+// already contain the base
+func (m *Manager) fastForwardSession(session *Session, force bool) (string, error) {
+	base := m.baseBranch(session)
+
+	oldBase, sessionHead, baseWorktree, err := m.prepareFinish(session, base)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := m.git.IsAncestor(oldBase, sessionHead)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("cannot fast-forward %s to %s: %s has moved on\nUse --rebase or --merge instead", base, session.Branch, base)
+	}
+
+	if err := m.advanceBase(base, baseWorktree, oldBase, sessionHead); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Fast-forwarded %s to %s (%.7s)\n", base, session.Name, sessionHead)
+
+	if err := m.restackChildren(session); err != nil {
+		return "", err
+	}
+	return sessionHead, m.deleteSession(session, force, true)
+}
+
+// prepareFinish resolves the base and session heads and finds the worktree
+// that has the base checked out. A worktree with the base checked out is
+// updated along with the ref, which is only safe if it has no uncommitted
+// changes.
+func (m *Manager) prepareFinish(session *Session, base string) (baseHead, sessionHead, baseWorktree string, err error) {
+	baseHead, err = m.git.ResolveRef("refs/heads/" + base)
+	if err != nil {
+		return "", "", "", fmt.Errorf("could not resolve base branch %s: %w", base, err)
+	}
+	sessionHead, err = m.git.ResolveRef(session.Branch)
+	if err != nil {
+		return "", "", "", fmt.Errorf("could not resolve session branch %s: %w", session.Branch, err)
+	}
+
+	baseWorktree, err = m.worktreeForBranch(base)
+	if err != nil {
+		return "", "", "", err
+	}
+	if baseWorktree != "" {
+		dirty, err := m.git.InWorktree(baseWorktree).HasTrackedChanges()
+		if err != nil {
+			return "", "", "", fmt.Errorf("could not check %s: %w", baseWorktree, err)
+		}
+		if dirty {
+			return "", "", "", fmt.Errorf("%s is checked out in %s with uncommitted changes\nCommit or stash them before finishing", base, baseWorktree)
+		}
+	}
+
+	return baseHead, sessionHead, baseWorktree, nil
+}
+
+// advanceBase moves the base branch from oldBase to commit, fast-forwarding
+// the worktree that has it checked out if there is one
+func (m *Manager) advanceBase(base, baseWorktree, oldBase, commit string) error {
+	var err error
+	if baseWorktree != "" {
+		err = m.git.InWorktree(baseWorktree).MergeFFOnly(commit)
+	} else {
+		err = m.git.UpdateRef("refs/heads/"+base, commit, oldBase)
+	}
+	if err != nil {
+		return fmt.Errorf("could not update %s: %w", base, err)
+	}
+	return nil
+}
+
+// baseBranch returns the branch a session was created from, which is the
+// branch it syncs with and is finished into
+func (m *Manager) baseBranch(session *Session) string {
+	if session.BaseBranch == "" {
+		m.hydrateBase(session)
+	}
+	return session.BaseBranch
+}
+
+// worktreeForBranch returns the path of the worktree that has branch
+// checked out, or "" if none does
+func (m *Manager) worktreeForBranch(branch string) (string, error) {
+	worktrees, err := m.git.WorktreeList()
+	if err != nil {
+		return "", err
+	}
+	for _, wt := range worktrees {
+		if !wt.Bare && wt.Branch == branch {
+			return wt.Path, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/emaland/ccs/internal/claude"
+)
+
+// ForkOptions contains options for forking a session
+type ForkOptions struct {
+	Count        int      // Number of forks; more than one are named <name>-1 to <name>-N
+	Conversation bool     // Copy Claude's conversation so each fork can continue it
+	NoClaude     bool     // Don't start Claude
+	NoTerminal   bool     // Don't create terminal windows
+	ClaudeArgs   []string // Arguments to pass to Claude
+}
+
+// forkNames returns the names of the sessions Fork creates
+func forkNames(newName string, count int) []string {
+	if count <= 1 {
+		return []string{newName}
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s-%d", newName, i+1)
+	}
+	return names
+}
+
+// Fork creates sessions that start from another session's HEAD, with its
+// uncommitted changes, so the same task can be taken in different
+// directions. Forks share the source session's base and are not stacked on
+// it, so any of them can be finished on its own.
+func (m *Manager) Fork(name, newName string, opts ForkOptions) ([]*Session, error) {
+	source, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	names := forkNames(newName, opts.Count)
+	for _, n := range names {
+		if err := ValidateName(n); err != nil {
+			return nil, err
+		}
+		if m.git.BranchExists(m.cfg.GetBranchName(n)) {
+			return nil, &ErrSessionExists{Name: n}
+		}
+	}
+
+	srcGit := m.git.InWorktree(source.Path)
+	head, err := srcGit.ResolveRef("HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	// Uncommitted work is carried over as uncommitted changes in each fork
+	var snapshot string
+	if clean, err := srcGit.IsClean(); err != nil {
+		return nil, err
+	} else if !clean {
+		if snapshot, err = srcGit.Snapshot("ccs: uncommitted changes in " + name); err != nil {
+			return nil, fmt.Errorf("could not snapshot uncommitted changes: %w", err)
+		}
+	}
+
+	claudeArgs := opts.ClaudeArgs
+	if opts.Conversation {
+		claudeArgs = append([]string{"--continue"}, claudeArgs...)
+	}
+
+	createOpts := CreateOptions{
+		FromSession: source.Parent,
+		NoClaude:    opts.NoClaude,
+		NoTerminal:  opts.NoTerminal,
+		ClaudeArgs:  claudeArgs,
+		startPoint:  head,
+		baseCommit:  source.BaseCommit,
+		forkOf:      name,
+		prepare: func(s *Session) error {
+			if snapshot != "" {
+				if err := m.git.InWorktree(s.Path).RestoreWorktree(snapshot); err != nil {
+					return fmt.Errorf("could not apply uncommitted changes: %w", err)
+				}
+			}
+			if opts.Conversation {
+				copied, err := claude.CopyLatestTranscript(source.Path, s.Path)
+				if err != nil {
+					return fmt.Errorf("could not copy conversation: %w", err)
+				}
+				if !copied {
+					fmt.Fprintf(os.Stderr, "Warning: %s has no conversation to copy\n", name)
+				}
+			}
+			return nil
+		},
+	}
+	if source.Parent == "" {
+		createOpts.From = source.BaseBranch
+	}
+	// Several Claudes can't share the current terminal
+	if len(names) > 1 && (opts.NoTerminal || m.terminal.Name() == "none") {
+		createOpts.NoClaude = true
+	}
+
+	var forks []*Session
+	for _, n := range names {
+		s, err := m.Create(n, createOpts)
+		if err != nil {
+			return forks, fmt.Errorf("could not create %s: %w", n, err)
+		}
+		forks = append(forks, s)
+	}
+	return forks, nil
+}
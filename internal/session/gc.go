@@ -0,0 +1,192 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/config"
+	"github.com/emaland/ccs/internal/disk"
+)
+
+// Actions the retention policy takes on a session
+const (
+	GCArchive = "archive"
+	GCDelete  = "delete"
+)
+
+// lastGCConfigKey records when the policy was last enforced automatically
+const lastGCConfigKey = "ccs.lastgc"
+
+// autoGCInterval is how often the policy is enforced automatically
+const autoGCInterval = 24 * time.Hour
+
+// GCAction is what the retention policy does to a session
+type GCAction struct {
+	Session    *Session  `json:"-"`
+	Name       string    `json:"session"`
+	Action     string    `json:"action"` // "archive" or "delete"
+	Reason     string    `json:"reason"`
+	LastActive time.Time `json:"last_active"`
+	Size       int64     `json:"size"` // Disk usage of the worktree in bytes
+	Error      string    `json:"error,omitempty"`
+}
+
+// PlanGC returns what the [gc] retention policy would do to the
+// repository's sessions now. Sessions Claude is running in, the current
+// session and sessions others are stacked on are kept.
+func (m *Manager) PlanGC(now time.Time) ([]*GCAction, error) {
+	policy := m.cfg.GC
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	sessions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	current, _ := m.GetCurrent()
+	cache := disk.OpenDefaultCache()
+
+	var actions []*GCAction
+	for _, s := range sessions {
+		if current != nil && current.Path == s.Path {
+			continue
+		}
+		if st := m.processes().State(s.Path); st == claude.StateRunning || st == claude.StateWaiting {
+			continue
+		}
+		if children, err := m.children(s); err != nil || len(children) > 0 {
+			continue
+		}
+
+		lastActive := m.lastActive(s)
+		wtGit := m.git.InWorktree(s.Path)
+		clean, err := wtGit.IsClean()
+		if err != nil {
+			continue
+		}
+		action, reason := gcDecision(policy, now.Sub(lastActive), m.isMerged(s), clean, s.BaseBranch)
+		if action == "" {
+			continue
+		}
+		// Sized through the cache shared with 'ccs du' so worktrees are only
+		// walked again when they changed
+		usage, _ := cache.Usage(wtGit, s.Path, false)
+		actions = append(actions, &GCAction{
+			Session:    s,
+			Name:       s.Name,
+			Action:     action,
+			Reason:     reason,
+			LastActive: lastActive,
+			Size:       usage.Total(),
+		})
+	}
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save disk usage cache: %v\n", err)
+	}
+	return actions, nil
+}
+
+// GC carries out planned actions, recording any failure on the action
+func (m *Manager) GC(actions []*GCAction) {
+	for _, a := range actions {
+		var err error
+		switch a.Action {
+		case GCArchive:
+			_, err = m.Archive(a.Name, false)
+		case GCDelete:
+			err = m.Delete(a.Name, true)
+		}
+		if err != nil {
+			a.Error = err.Error()
+		}
+	}
+}
+
+// AutoGCDue reports whether automatic enforcement of the retention policy
+// is on and hasn't run in this repository for a day, and marks it as run
+func (m *Manager) AutoGCDue(now time.Time) bool {
+	if !m.cfg.GC.Auto || !m.cfg.GC.Enabled() || m.state == nil {
+		return false
+	}
+	if last, err := m.git.ConfigGet(lastGCConfigKey); err == nil {
+		var unix int64
+		if _, err := fmt.Sscan(strings.TrimSpace(last), &unix); err == nil && now.Sub(time.Unix(unix, 0)) < autoGCInterval {
+			return false
+		}
+	}
+	m.git.ConfigSet(lastGCConfigKey, fmt.Sprint(now.Unix()))
+	return true
+}
+
+// gcDecision applies the retention policy to a session idle for idle.
+// Merged sessions with uncommitted changes are archived rather than
+// deleted, so the changes survive.
+func gcDecision(policy config.GCConfig, idle time.Duration, merged, clean bool, base string) (action, reason string) {
+	days := int(idle / (24 * time.Hour))
+	if merged && clean && policy.DeleteMergedAfterDays > 0 && days >= policy.DeleteMergedAfterDays {
+		return GCDelete, fmt.Sprintf("merged into %s, idle %d days", base, days)
+	}
+	if policy.ArchiveAfterDays > 0 && days >= policy.ArchiveAfterDays {
+		return GCArchive, fmt.Sprintf("idle %d days", days)
+	}
+	return "", ""
+}
+
+// Touch records that a session was used, which keeps the retention policy
+// from expiring it
+func (m *Manager) Touch(session *Session) {
+	if m.state == nil {
+		return
+	}
+	if err := m.state.UpdateLastAccess(session.Path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not update last access: %v\n", err)
+	}
+}
+
+// lastActive returns when a session was last switched to, resumed or
+// looked at, committed to, or worked in by Claude
+func (m *Manager) lastActive(session *Session) time.Time {
+	last := worktreeCreated(session.Path)
+	later := func(t time.Time) {
+		if t.After(last) {
+			last = t
+		}
+	}
+	if m.state != nil {
+		if st := m.state.GetSession(session.Path); st != nil {
+			later(st.CreatedAt)
+			later(st.LastAccess)
+		}
+	}
+	if t := m.GetClaudeInfo(session).LastActivity; t != nil {
+		later(*t)
+	}
+	// A branch still at its base commit hasn't been committed to
+	if refs, err := m.git.RefList("refs/heads/" + session.Branch); err == nil {
+		for _, ref := range refs {
+			if ref.Name == "refs/heads/"+session.Branch && ref.Commit != session.BaseCommit {
+				later(ref.Time)
+			}
+		}
+	}
+	return last
+}
+
+// isMerged reports whether a session has commits and its branch is
+// contained in its base. A session without commits of its own is not
+// merged, just unused.
+func (m *Manager) isMerged(session *Session) bool {
+	if session.BaseCommit == "" {
+		return false
+	}
+	head, err := m.git.ResolveRef(session.Branch)
+	if err != nil || head == session.BaseCommit {
+		return false
+	}
+	merged, err := m.git.IsAncestor(head, session.BaseBranch)
+	return err == nil && merged
+}
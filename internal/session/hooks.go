@@ -0,0 +1,136 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/emaland/ccs/internal/config"
+)
+
+// Lifecycle hook names, as in [hooks] and CCS_HOOK
+const (
+	HookPreCreate  = "pre_create"
+	HookPostCreate = "post_create"
+	HookPreSwitch  = "pre_switch"
+	HookPostSwitch = "post_switch"
+	HookPrePause   = "pre_pause"
+	HookPostResume = "post_resume"
+	HookPreFinish  = "pre_finish"
+	HookPostFinish = "post_finish"
+	HookPreDelete  = "pre_delete"
+	HookPostDelete = "post_delete"
+)
+
+// hookWaitDelay is how long a timed out hook's output is waited for after
+// it is killed, in case it left children holding stdout or stderr open
+const hookWaitDelay = 2 * time.Second
+
+// hook returns the configured hook for a name
+func (m *Manager) hook(name string) config.Hook {
+	h := m.cfg.Hooks
+	switch name {
+	case HookPreCreate:
+		return h.PreCreate
+	case HookPostCreate:
+		return h.PostCreate
+	case HookPreSwitch:
+		return h.PreSwitch
+	case HookPostSwitch:
+		return h.PostSwitch
+	case HookPrePause:
+		return h.PrePause
+	case HookPostResume:
+		return h.PostResume
+	case HookPreFinish:
+		return h.PreFinish
+	case HookPostFinish:
+		return h.PostFinish
+	case HookPreDelete:
+		return h.PreDelete
+	case HookPostDelete:
+		return h.PostDelete
+	}
+	return config.Hook{}
+}
+
+// failureMode returns what a failing command of a hook does. Hooks that run
+// before an operation, and post_create, which cleans up the new session,
+// fail by default; other post hooks warn, as their operation has happened.
+func failureMode(name string, hook config.Hook) string {
+	if hook.OnFailure != "" {
+		return hook.OnFailure
+	}
+	if strings.HasPrefix(name, "pre_") || name == HookPostCreate {
+		return config.HookFail
+	}
+	return config.HookWarn
+}
+
+// runHook runs the commands of a lifecycle hook in order, in the session's
+// worktree or the repository root if it has none. Besides the session's
+// environment, hooks get CCS_HOOK, CCS_SESSION_NAME and
+// CCS_FINISH_STRATEGY, which is only set for finish hooks. Output goes to
+// stderr so it never mixes with a command's output, such as the cd printed
+// by switch. An error is returned only if a command fails and the hook
+// fails rather than warns.
+func (m *Manager) runHook(name string, session *Session, finishStrategy string) error {
+	hook := m.hook(name)
+	if len(hook.Commands) == 0 {
+		return nil
+	}
+
+	dir := session.Path
+	if _, err := os.Stat(dir); err != nil {
+		dir = m.git.RepoRoot()
+	}
+	env := append(os.Environ(), m.sessionEnv(session)...)
+	env = append(env,
+		"CCS_HOOK="+name,
+		"CCS_SESSION_NAME="+session.Name,
+		"CCS_FINISH_STRATEGY="+finishStrategy,
+	)
+
+	for _, command := range hook.Commands {
+		err := runHookCommand(command, dir, env, hook.Timeout)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%s hook failed: %s: %w", name, command, err)
+		if failureMode(name, hook) == config.HookFail {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return nil
+}
+
+func runHookCommand(command, dir string, env []string, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if timeout > 0 {
+		// Hooks without a timeout stay in our process group so Ctrl-C
+		// reaches them
+		cmd.WaitDelay = hookWaitDelay
+		killProcessGroup(cmd)
+	}
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
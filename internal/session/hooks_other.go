@@ -0,0 +1,9 @@
+//go:build !unix
+
+package session
+
+import "os/exec"
+
+// killProcessGroup is a no-op where process groups are unavailable; only
+// the hook's shell is killed when it times out
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,179 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/emaland/ccs/internal/git"
+	"github.com/emaland/ccs/internal/state"
+)
+
+// Importable is a session worktree that is not yet in global state
+type Importable struct {
+	*Session
+	CreatedAt time.Time
+	Detected  bool // Base was inferred from history rather than recorded at creation
+}
+
+// FindImportable returns the repository's session worktrees that global
+// state does not know about, with their bases and creation times recovered
+func (m *Manager) FindImportable() ([]*Importable, error) {
+	worktrees, err := m.git.WorktreeList()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := m.cfg.BranchPrefix
+	var found []*Importable
+	for _, wt := range worktrees {
+		if wt.Bare || wt.Prunable != "" || !strings.HasPrefix(wt.Branch, prefix) {
+			continue
+		}
+		if m.state != nil && m.state.GetSession(wt.Path) != nil {
+			continue
+		}
+		found = append(found, m.importable(wt.Path, wt.Branch))
+	}
+	return found, nil
+}
+
+// Import adds a session found by FindImportable to global state, and
+// records an inferred base in the branch config so later commands agree
+func (m *Manager) Import(imp *Importable) error {
+	if imp.Detected {
+		m.git.ConfigSet(baseConfigKey(imp.Branch), imp.BaseBranch)
+		m.git.ConfigSet(baseCommitConfigKey(imp.Branch), imp.BaseCommit)
+	}
+	if m.state == nil {
+		return nil
+	}
+	return m.state.AddSession(state.SessionState{
+		Name:       imp.Name,
+		RepoPath:   m.git.RepoRoot(),
+		RepoName:   m.git.RepoName(),
+		WorkTree:   imp.Path,
+		Branch:     imp.Branch,
+		BaseBranch: imp.BaseBranch,
+		BaseCommit: imp.BaseCommit,
+		Parent:     imp.Parent,
+		CreatedAt:  imp.CreatedAt,
+		LastAccess: time.Now(),
+	})
+}
+
+// importable builds the import for a worktree, preferring the base recorded
+// in the branch config and detecting one otherwise
+func (m *Manager) importable(path, branch string) *Importable {
+	prefix := m.cfg.BranchPrefix
+	imp := &Importable{
+		Session: &Session{
+			Name:     strings.TrimPrefix(branch, prefix),
+			Path:     path,
+			Branch:   branch,
+			RepoRoot: m.git.RepoRoot(),
+		},
+		CreatedAt: worktreeCreated(path),
+	}
+
+	imp.BaseBranch, _ = m.git.ConfigGet(baseConfigKey(branch))
+	imp.BaseCommit, _ = m.git.ConfigGet(baseCommitConfigKey(branch))
+	if imp.BaseBranch == "" {
+		imp.BaseBranch, imp.BaseCommit = m.detectBase(branch)
+		imp.Detected = true
+	} else if imp.BaseCommit == "" {
+		imp.BaseCommit, _ = m.git.MergeBase(imp.BaseBranch, branch)
+	}
+
+	if strings.HasPrefix(imp.BaseBranch, prefix) {
+		imp.Parent = strings.TrimPrefix(imp.BaseBranch, prefix)
+	}
+	return imp
+}
+
+// detectBase guesses which branch a session was created from: of its
+// local upstream, the configured default base, the usual trunk names and the
+// other session branches, the one it forked from most recently. Ties go to
+// the earlier candidate, so a session forked from a parent session that has
+// no commits of its own is attributed to the trunk.
+func (m *Manager) detectBase(branch string) (base, baseCommit string) {
+	head, err := m.git.ResolveRef(branch)
+	if err != nil {
+		return m.cfg.DefaultBase, ""
+	}
+
+	var candidates []string
+	if upstream := m.localUpstream(branch); upstream != "" {
+		candidates = append(candidates, upstream)
+	}
+	candidates = append(candidates, m.cfg.DefaultBase, "main", "master", "develop", "trunk")
+	trunks := len(candidates)
+	if others, err := m.git.BranchList(m.cfg.BranchPrefix); err == nil {
+		candidates = append(candidates, others...)
+	}
+
+	best := -1
+	seen := map[string]bool{branch: true}
+	for i, c := range candidates {
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		mergeBase, err := m.git.MergeBase(c, branch)
+		if err != nil {
+			continue
+		}
+		// A session that contains the whole branch was stacked on it, not
+		// the other way round
+		if i >= trunks && mergeBase == head {
+			continue
+		}
+		ahead, err := m.git.CommitCount(mergeBase, branch)
+		if err != nil {
+			continue
+		}
+		if best < 0 || ahead < best {
+			best = ahead
+			base, baseCommit = c, mergeBase
+		}
+	}
+
+	if base == "" {
+		base = m.cfg.DefaultBase
+	}
+	return base, baseCommit
+}
+
+// localUpstream returns the local branch a branch tracks, mapping a
+// remote-tracking upstream such as origin/main to main. Sessions finish into
+// their base, so an upstream with no local branch, or the branch's own
+// remote copy, is no candidate and "" is returned.
+func (m *Manager) localUpstream(branch string) string {
+	upstream, err := m.git.Upstream(branch)
+	if err != nil || strings.HasSuffix(upstream, "/"+branch) {
+		return ""
+	}
+	if m.git.BranchExists(upstream) {
+		return upstream
+	}
+	if _, local, ok := strings.Cut(upstream, "/"); ok && local != branch && m.git.BranchExists(local) {
+		return local
+	}
+	return ""
+}
+
+// worktreeCreated returns when a linked worktree was added. Git writes the
+// commondir file in the worktree's admin directory once, at creation, so its
+// modification time survives later checkouts and commits.
+func worktreeCreated(path string) time.Time {
+	if gitDir, err := git.GitDir(path); err == nil {
+		for _, p := range []string{filepath.Join(gitDir, "commondir"), gitDir} {
+			if info, err := os.Stat(p); err == nil {
+				return info.ModTime()
+			}
+		}
+	}
+	return time.Now()
+}
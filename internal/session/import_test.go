@@ -0,0 +1,57 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/emaland/ccs/internal/config"
+	"github.com/emaland/ccs/internal/terminal"
+)
+
+func TestDetectBase(t *testing.T) {
+	dir, g := newTestRepo(t)
+	commitOn := func(branch, from string) string {
+		gitRun(t, dir, "checkout", "--quiet", "-B", branch, from)
+		gitRun(t, dir, "commit", "--quiet", "--allow-empty", "-m", branch)
+		return gitRun(t, dir, "rev-parse", "HEAD")
+	}
+
+	initial := gitRun(t, dir, "rev-parse", "HEAD")
+	feature := commitOn("ccs/feature", "main")
+	commitOn("ccs/stacked", "ccs/feature")
+	advanced := commitOn("main", "main")
+	gitRun(t, dir, "branch", "ccs/parent", "main")
+	commitOn("ccs/child", "ccs/parent")
+
+	// Branches tracking remote branches, one of which has no local branch
+	gitRun(t, dir, "remote", "add", "origin", dir)
+	gitRun(t, dir, "update-ref", "refs/remotes/origin/main", initial)
+	gitRun(t, dir, "update-ref", "refs/remotes/origin/develop", initial)
+	commitOn("ccs/tracked", "origin/main")
+	gitRun(t, dir, "branch", "--quiet", "--set-upstream-to=origin/main", "ccs/tracked")
+	commitOn("ccs/remote-only", "origin/develop")
+	gitRun(t, dir, "branch", "--quiet", "--set-upstream-to=origin/develop", "ccs/remote-only")
+	gitRun(t, dir, "checkout", "--quiet", "main")
+
+	m := NewManager(&config.Config{BranchPrefix: "ccs/", DefaultBase: "main"}, g, &terminal.NoopTerminal{}, nil)
+
+	tests := []struct {
+		branch     string
+		base       string
+		baseCommit string
+	}{
+		{"ccs/feature", "main", initial},
+		{"ccs/stacked", "ccs/feature", feature},
+		// A parent without commits of its own is indistinguishable from main
+		{"ccs/child", "main", advanced},
+		{"ccs/tracked", "main", initial},
+		{"ccs/remote-only", "main", initial},
+		{"ccs/missing", "main", ""},
+	}
+
+	for _, tt := range tests {
+		base, baseCommit := m.detectBase(tt.branch)
+		if base != tt.base || baseCommit != tt.baseCommit {
+			t.Errorf("%s: expected %s at %.7s, got %s at %.7s", tt.branch, tt.base, tt.baseCommit, base, baseCommit)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emaland/ccs/internal/disk"
+)
+
+// copyLocalFiles brings files git doesn't check out, such as .env, from the
+// repository into a new worktree as set in [worktree]. Paths the worktree
+// already has are left alone, so a file listed twice goes the first way:
+// copy, then reflink, then symlink.
+func (m *Manager) copyLocalFiles(worktree string) error {
+	root := m.git.RepoRoot()
+	rules := []struct {
+		patterns []string
+		apply    func(src, dst string) error
+	}{
+		{m.cfg.Worktree.Copy, func(src, dst string) error { return disk.Copy(src, dst, false) }},
+		{m.cfg.Worktree.Reflink, func(src, dst string) error { return disk.Copy(src, dst, true) }},
+		{m.cfg.Worktree.Symlink, os.Symlink},
+	}
+
+	for _, rule := range rules {
+		for _, pattern := range rule.patterns {
+			matches, err := filepath.Glob(filepath.Join(root, pattern))
+			if err != nil {
+				return fmt.Errorf("bad pattern %q in [worktree]: %w", pattern, err)
+			}
+			for _, src := range matches {
+				rel, err := filepath.Rel(root, src)
+				if err != nil || !localFile(rel) {
+					continue
+				}
+				dst := filepath.Join(worktree, rel)
+				if _, err := os.Lstat(dst); err == nil {
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+					return err
+				}
+				if err := rule.apply(src, dst); err != nil {
+					return fmt.Errorf("could not bring %s into the worktree: %w", rel, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// localFile reports whether a path in the repository can be brought into
+// a worktree: git's own data and local worktrees can't
+func localFile(rel string) bool {
+	first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return rel != "." && first != ".." && first != ".git" && first != ".worktrees"
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emaland/ccs/internal/state"
+)
+
+// Kinds of drift between state, git and the terminal
+const (
+	DriftUnlinked     = "unlinked"      // Worktree whose link with the repository is broken
+	DriftPrunable     = "prunable"      // Worktree git knows about whose directory is gone
+	DriftUntracked    = "untracked"     // Session worktree missing from state
+	DriftWorktreeGone = "worktree-gone" // State entry whose worktree is gone but branch remains
+	DriftBranchGone   = "branch-gone"   // State entry whose worktree and branch are gone
+	DriftOrphanBranch = "orphan-branch" // Session branch with no worktree
+	DriftDeadWindow   = "dead-window"   // Terminal window for a session that no longer exists
+)
+
+// Drift is one inconsistency found by FindDrift
+type Drift struct {
+	Kind    string
+	Session string
+	Path    string
+	Branch  string
+	Problem string // What is wrong
+	Action  string // What FixDrift does about it
+	Fixable bool   // False if it needs a decision only the user can make
+}
+
+// FindDrift compares global state with the repository's worktrees and
+// branches and the terminal's windows, and reports every mismatch. Drift is
+// returned in the order it should be fixed.
+func (m *Manager) FindDrift() ([]Drift, error) {
+	prefix := m.cfg.BranchPrefix
+	repoRoot := m.git.RepoRoot()
+
+	worktrees, err := m.git.WorktreeList()
+	if err != nil {
+		return nil, err
+	}
+	branches, err := m.git.BranchList(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []state.SessionState
+	if m.state != nil {
+		entries = m.state.GetSessionsForRepo(repoRoot)
+	}
+	tracked := map[string]bool{}
+	for _, e := range entries {
+		if !e.Archived {
+			tracked[e.WorkTree] = true
+		}
+	}
+
+	var unlinked, prunable, untracked, gone, orphans, windows []Drift
+
+	listed := map[string]bool{}     // Worktree paths git knows about
+	checkedOut := map[string]bool{} // Branches in live worktrees
+	live := map[string]bool{}       // Names of live sessions
+	for _, wt := range worktrees {
+		listed[wt.Path] = true
+		if wt.Bare || !strings.HasPrefix(wt.Branch, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(wt.Branch, prefix)
+
+		if wt.Prunable != "" {
+			prunable = append(prunable, Drift{
+				Kind: DriftPrunable, Session: name, Path: wt.Path, Branch: wt.Branch,
+				Problem: "worktree is prunable: " + wt.Prunable,
+				Action:  "git worktree prune",
+				Fixable: true,
+			})
+			continue
+		}
+
+		checkedOut[wt.Branch] = true
+		live[name] = true
+		if brokenWorktreeLink(wt.Path) {
+			unlinked = append(unlinked, Drift{
+				Kind: DriftUnlinked, Session: name, Path: wt.Path, Branch: wt.Branch,
+				Problem: "worktree's .git file points to a missing repository (was the repository moved?)",
+				Action:  "git worktree repair",
+				Fixable: true,
+			})
+		}
+		if !tracked[wt.Path] {
+			untracked = append(untracked, Drift{
+				Kind: DriftUntracked, Session: name, Path: wt.Path, Branch: wt.Branch,
+				Problem: "worktree is not in state",
+				Action:  "add to state",
+				Fixable: true,
+			})
+		}
+	}
+
+	known := map[string]bool{} // Names this repo has used for sessions
+	for _, e := range entries {
+		known[e.Name] = true
+		if listed[e.WorkTree] || e.Archived {
+			continue
+		}
+
+		d := Drift{Session: e.Name, Path: e.WorkTree, Branch: e.Branch, Fixable: true}
+		switch {
+		case isWorktreeDir(e.WorkTree):
+			d.Kind = DriftUnlinked
+			d.Problem = "worktree exists but git does not list it"
+			d.Action = "git worktree repair"
+			checkedOut[e.Branch] = true
+			live[e.Name] = true
+			unlinked = append(unlinked, d)
+			continue
+		case m.git.BranchExists(e.Branch):
+			d.Kind = DriftWorktreeGone
+			d.Problem = "worktree is gone, branch remains"
+		default:
+			d.Kind = DriftBranchGone
+			d.Problem = "worktree and branch are gone"
+		}
+		d.Action = "remove from state"
+		gone = append(gone, d)
+	}
+
+	for _, branch := range branches {
+		if checkedOut[branch] {
+			continue
+		}
+		name := strings.TrimPrefix(branch, prefix)
+		known[name] = true
+
+		base, _ := m.git.ConfigGet(baseConfigKey(branch))
+		if base == "" {
+			base = m.cfg.DefaultBase
+		}
+		d := Drift{Kind: DriftOrphanBranch, Session: name, Branch: branch}
+		if merged, err := m.git.IsAncestor(branch, base); err == nil && merged {
+			d.Problem = "branch has no worktree and is merged into " + base
+			d.Action = "delete branch"
+			d.Fixable = true
+		} else {
+			d.Problem = "branch has no worktree and is not merged into " + base
+			d.Action = fmt.Sprintf("none; restore it with 'git worktree add <path> %s' or delete it with 'git branch -D %s'", branch, branch)
+		}
+		orphans = append(orphans, d)
+	}
+
+	if m.terminal.Name() != "none" {
+		if m.state != nil {
+			events, _ := m.state.Events(state.EventFilter{RepoPath: repoRoot})
+			for _, ev := range events {
+				known[ev.Session] = true
+			}
+			// Windows are named after sessions alone, so one may belong to a
+			// session of the same name in another repository
+			for _, e := range m.state.GetAllSessions() {
+				if e.RepoPath != repoRoot && !e.Archived {
+					live[e.Name] = true
+				}
+			}
+		}
+		names, _ := m.terminal.ListWindows()
+		for _, w := range names {
+			if known[w] && !live[w] {
+				windows = append(windows, Drift{
+					Kind: DriftDeadWindow, Session: w,
+					Problem: fmt.Sprintf("%s window for a session that no longer exists", m.terminal.Name()),
+					Action:  "close window",
+					Fixable: true,
+				})
+			}
+		}
+	}
+
+	var drift []Drift
+	for _, group := range [][]Drift{unlinked, prunable, untracked, gone, orphans, windows} {
+		drift = append(drift, group...)
+	}
+	return drift, nil
+}
+
+// FixDrift repairs one inconsistency found by FindDrift
+func (m *Manager) FixDrift(d Drift) error {
+	if !d.Fixable {
+		return fmt.Errorf("%s needs to be resolved manually", d.Session)
+	}
+
+	switch d.Kind {
+	case DriftUnlinked:
+		return m.git.WorktreeRepair(d.Path)
+
+	case DriftPrunable:
+		if err := m.git.WorktreePrune(); err != nil {
+			return err
+		}
+		if m.state != nil {
+			return m.state.RemoveSession(d.Path)
+		}
+		return nil
+
+	case DriftUntracked:
+		return m.Import(m.importable(d.Path, d.Branch))
+
+	case DriftWorktreeGone, DriftBranchGone:
+		if m.state != nil {
+			return m.state.RemoveSession(d.Path)
+		}
+		return nil
+
+	case DriftOrphanBranch:
+		return m.git.BranchDelete(d.Branch, false)
+
+	case DriftDeadWindow:
+		return m.terminal.CloseWindow(d.Session)
+	}
+
+	return fmt.Errorf("unknown drift %q", d.Kind)
+}
+
+// brokenWorktreeLink reports whether a worktree's .git file points at a
+// directory that no longer exists
+func brokenWorktreeLink(path string) bool {
+	data, err := os.ReadFile(filepath.Join(path, ".git"))
+	if err != nil {
+		return false
+	}
+	gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return false
+	}
+	_, err = os.Stat(gitdir)
+	return os.IsNotExist(err)
+}
+
+// isWorktreeDir reports whether path looks like a linked worktree: a
+// directory with a .git file pointing at the repository
+func isWorktreeDir(path string) bool {
+	info, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil && !info.IsDir()
+}
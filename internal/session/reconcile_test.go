@@ -0,0 +1,190 @@
+package session
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emaland/ccs/internal/config"
+	"github.com/emaland/ccs/internal/git"
+	"github.com/emaland/ccs/internal/state"
+	"github.com/emaland/ccs/internal/terminal"
+)
+
+// newTestRepo creates a repository with one commit on main
+func newTestRepo(t *testing.T) (string, *git.ExecGit) {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir = filepath.Join(dir, "repo")
+	gitRun(t, "", "init", "--quiet", "--initial-branch=main", dir)
+	gitRun(t, dir, "commit", "--quiet", "--allow-empty", "-m", "initial")
+	g, err := git.NewExecGit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, g
+}
+
+// gitRun runs a git command in dir, failing the test if it fails
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestBrokenWorktreeLink(t *testing.T) {
+	dir := t.TempDir()
+	repoGitDir := filepath.Join(dir, "repo", ".git", "worktrees", "feature")
+	if err := os.MkdirAll(repoGitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		dotGit   string // Contents of a .git file, "dir" for a directory or "" for none
+		broken   bool
+		worktree bool
+	}{
+		{"linked", "gitdir: " + repoGitDir + "\n", false, true},
+		{"repository moved", "gitdir: " + filepath.Join(dir, "moved", ".git", "worktrees", "feature") + "\n", true, true},
+		{"not a gitdir file", "something else\n", false, true},
+		{"main checkout", "dir", false, false},
+		{"not a repository", "", false, false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-"))
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		switch tt.dotGit {
+		case "":
+		case "dir":
+			os.Mkdir(filepath.Join(path, ".git"), 0755)
+		default:
+			os.WriteFile(filepath.Join(path, ".git"), []byte(tt.dotGit), 0644)
+		}
+
+		if got := brokenWorktreeLink(path); got != tt.broken {
+			t.Errorf("%s: brokenWorktreeLink = %v, want %v", tt.name, got, tt.broken)
+		}
+		if got := isWorktreeDir(path); got != tt.worktree {
+			t.Errorf("%s: isWorktreeDir = %v, want %v", tt.name, got, tt.worktree)
+		}
+	}
+}
+
+func TestFindDrift(t *testing.T) {
+	dir, g := newTestRepo(t)
+
+	// A branch merged into main, one that isn't, a live worktree and one
+	// whose directory was deleted
+	gitRun(t, dir, "checkout", "--quiet", "-b", "ccs/merged")
+	gitRun(t, dir, "commit", "--quiet", "--allow-empty", "-m", "merged work")
+	gitRun(t, dir, "checkout", "--quiet", "main")
+	gitRun(t, dir, "merge", "--quiet", "--ff-only", "ccs/merged")
+	gitRun(t, dir, "checkout", "--quiet", "-b", "ccs/unmerged")
+	gitRun(t, dir, "commit", "--quiet", "--allow-empty", "-m", "unmerged work")
+	gitRun(t, dir, "checkout", "--quiet", "main")
+	gitRun(t, dir, "worktree", "add", "--quiet", "-b", "ccs/live", filepath.Join(dir, "..", "live"))
+	gitRun(t, dir, "worktree", "add", "--quiet", "-b", "ccs/deleted", filepath.Join(dir, "..", "deleted"))
+	if err := os.RemoveAll(filepath.Join(dir, "..", "deleted")); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(&config.Config{BranchPrefix: "ccs/", DefaultBase: "main"}, g, &terminal.NoopTerminal{}, nil)
+	drift, err := m.FindDrift()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type found struct {
+		session string
+		kind    string
+		fixable bool
+	}
+	var got []found
+	for _, d := range drift {
+		got = append(got, found{d.Session, d.Kind, d.Fixable})
+	}
+	// The deleted worktree's branch is orphaned once it is pruned
+	want := []found{
+		{"deleted", DriftPrunable, true},
+		{"live", DriftUntracked, true},
+		{"deleted", DriftOrphanBranch, true},
+		{"merged", DriftOrphanBranch, true},
+		{"unmerged", DriftOrphanBranch, false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected drift %v, got %v", want, got)
+	}
+
+	for _, d := range drift {
+		if err := m.FixDrift(d); d.Fixable != (err == nil) {
+			t.Errorf("%s %s: FixDrift error = %v, fixable %v", d.Session, d.Kind, err, d.Fixable)
+		}
+	}
+	for branch, exists := range map[string]bool{"ccs/deleted": false, "ccs/merged": false, "ccs/unmerged": true, "ccs/live": true} {
+		if g.BranchExists(branch) != exists {
+			t.Errorf("%s: expected exists = %v after fixing", branch, exists)
+		}
+	}
+}
+
+// windowsTerminal is a terminal with a fixed set of windows
+type windowsTerminal struct {
+	terminal.NoopTerminal
+	windows []string
+}
+
+func (w *windowsTerminal) Name() string                   { return "tmux" }
+func (w *windowsTerminal) ListWindows() ([]string, error) { return w.windows, nil }
+
+func TestFindDriftDeadWindows(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir, g := newTestRepo(t)
+
+	st, err := state.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// This repository once had sessions fix and old; another repository
+	// has a live session named fix
+	for _, name := range []string{"fix", "old"} {
+		if err := st.RecordEvent(state.Event{Type: state.EventCreated, Session: name, RepoPath: dir}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.AddSession(state.SessionState{Name: "fix", RepoPath: "/src/other", WorkTree: "/wt/other/fix", Branch: "ccs/fix"}); err != nil {
+		t.Fatal(err)
+	}
+
+	term := &windowsTerminal{windows: []string{"fix", "old", "unrelated"}}
+	m := NewManager(&config.Config{BranchPrefix: "ccs/", DefaultBase: "main"}, g, term, st)
+	drift, err := m.FindDrift()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dead []string
+	for _, d := range drift {
+		if d.Kind == DriftDeadWindow {
+			dead = append(dead, d.Session)
+		}
+	}
+	if !reflect.DeepEqual(dead, []string{"old"}) {
+		t.Errorf("expected only old's window to be dead, got %v", dead)
+	}
+}
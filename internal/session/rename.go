@@ -0,0 +1,130 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/state"
+)
+
+// Rename renames a session's branch, worktree directory and terminal
+// window, and moves its Claude transcripts so the conversation can still be
+// continued from the new directory. Sessions stacked on it follow the new
+// branch name.
+func (m *Manager) Rename(oldName, newName string) (*Session, error) {
+	if err := ValidateName(newName); err != nil {
+		return nil, err
+	}
+	session, err := m.Get(oldName)
+	if err != nil {
+		return nil, err
+	}
+	if oldName == newName {
+		return session, nil
+	}
+
+	newBranch := m.cfg.GetBranchName(newName)
+	if m.git.BranchExists(newBranch) {
+		return nil, &ErrSessionExists{Name: newName}
+	}
+	if err := m.checkNotArchived(newName); err != nil {
+		return nil, err
+	}
+
+	// Claude keeps writing to the transcript directory for the path it was
+	// started in, so the conversation would be split
+	if err := m.checkClaudeStopped(session); err != nil {
+		return nil, err
+	}
+
+	children, err := m.children(session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Worktrees ccs created are named after the session; others keep their
+	// directory
+	newPath := session.Path
+	if filepath.Base(session.Path) == oldName {
+		newPath = filepath.Join(filepath.Dir(session.Path), newName)
+		if _, err := os.Lstat(newPath); err == nil {
+			return nil, fmt.Errorf("%s already exists", newPath)
+		}
+		if err := m.git.WorktreeMove(session.Path, newPath); err != nil {
+			return nil, fmt.Errorf("could not move worktree: %w", err)
+		}
+	}
+
+	// Renaming the branch also renames its config section, and with it the
+	// recorded base
+	if err := m.git.BranchRename(session.Branch, newBranch); err != nil {
+		if newPath != session.Path {
+			m.git.WorktreeMove(newPath, session.Path)
+		}
+		return nil, fmt.Errorf("could not rename branch: %w", err)
+	}
+
+	if err := m.moveCheckpoints(oldName, newName); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not move checkpoints: %v\n", err)
+	}
+
+	if newPath != session.Path {
+		if err := claude.MoveTranscripts(session.Path, newPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not move Claude transcripts: %v\n", err)
+		}
+	}
+
+	if m.terminal.Name() != "none" && m.terminal.WindowExists(oldName) {
+		if err := m.terminal.RenameWindow(oldName, newName); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not rename %s window: %v\n", m.terminal.Name(), err)
+		}
+	}
+
+	renamed := *session
+	renamed.Name = newName
+	renamed.Path = newPath
+	renamed.Branch = newBranch
+
+	if m.state != nil {
+		entry := state.SessionState{CreatedAt: time.Now()}
+		if old := m.state.GetSession(session.Path); old != nil {
+			entry = *old
+		}
+		entry.Name = newName
+		entry.RepoPath = m.git.RepoRoot()
+		entry.RepoName = m.git.RepoName()
+		entry.WorkTree = newPath
+		entry.Branch = newBranch
+		entry.BaseBranch = renamed.BaseBranch
+		entry.BaseCommit = renamed.BaseCommit
+		entry.Parent = renamed.Parent
+		entry.LastAccess = time.Now()
+
+		if newPath != session.Path {
+			m.state.RemoveSession(session.Path)
+		}
+		if err := m.state.AddSession(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save state: %v\n", err)
+		}
+	}
+
+	for _, child := range children {
+		if err := m.setBase(child, newBranch, child.BaseCommit, newName); err != nil {
+			return &renamed, err
+		}
+		key := "branch." + child.Branch + ".gh-merge-base"
+		if v, _ := m.git.ConfigGet(key); v == session.Branch {
+			m.git.ConfigSet(key, newBranch)
+		}
+	}
+
+	if err := m.writeEnvFile(&renamed); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write %s/env: %v\n", envDir, err)
+	}
+
+	m.recordEvent(&renamed, state.EventRenamed, "from "+oldName, "")
+	return &renamed, nil
+}
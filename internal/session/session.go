@@ -23,6 +23,7 @@ type Session struct {
 	Branch     string
 	BaseBranch string
 	BaseCommit string
+	Parent     string // Session this one is stacked on, if any
 	RepoRoot   string
 }
 
@@ -39,11 +40,12 @@ type Manager struct {
 	cfg      *config.Config
 	git      git.Git
 	terminal terminal.Terminal
-	state    *state.Manager
+	state    state.Store
+	procs    *claude.ProcessTable
 }
 
 // NewManager creates a new session manager
-func NewManager(cfg *config.Config, g git.Git, term terminal.Terminal, stateMgr *state.Manager) *Manager {
+func NewManager(cfg *config.Config, g git.Git, term terminal.Terminal, stateMgr state.Store) *Manager {
 	return &Manager{
 		cfg:      cfg,
 		git:      g,
@@ -73,17 +75,47 @@ func (m *Manager) Create(name string, opts CreateOptions) (*Session, error) {
 		return nil, err
 	}
 
+	if err := m.checkNotArchived(name); err != nil {
+		return nil, err
+	}
+
 	// Determine base - always use configured default (main) unless --from specified
 	baseBranch := opts.From
+	if opts.FromSession != "" {
+		if opts.From != "" {
+			return nil, fmt.Errorf("cannot use both a base ref and a parent session")
+		}
+		parent, err := m.Get(opts.FromSession)
+		if err != nil {
+			return nil, err
+		}
+		baseBranch = parent.Branch
+	}
 	if baseBranch == "" {
 		baseBranch = m.cfg.DefaultBase
 	}
+	// Sessions sync with and finish into their base, so it has to be a
+	// branch rather than a commit, tag or remote-tracking ref
+	baseBranch = strings.TrimPrefix(baseBranch, "refs/heads/")
+	if !m.git.BranchExists(baseBranch) {
+		return nil, fmt.Errorf("base %q is not a local branch\n\nSessions are finished into their base. Create a branch to start from first:\n  git branch <name> %s", baseBranch, baseBranch)
+	}
 
 	baseCommit, err := m.git.ResolveRef(baseBranch)
 	if err != nil {
 		return nil, fmt.Errorf("could not resolve base ref %q: %w", baseBranch, err)
 	}
 
+	// A session started part way along its base diverged where that
+	// starting point did
+	startPoint := baseBranch
+	if opts.startPoint != "" {
+		startPoint = opts.startPoint
+		if baseCommit, err = m.forkPoint(m.git, baseBranch, startPoint, opts.baseCommit); err != nil {
+			return nil, err
+		}
+	}
+
 	// Determine worktree path
 	var worktreePath string
 	if opts.Here {
@@ -91,6 +123,11 @@ func (m *Manager) Create(name string, opts CreateOptions) (*Session, error) {
 	} else {
 		worktreePath = m.cfg.GetWorktreePath(m.git.RepoName(), name)
 	}
+	// State is keyed by path, so it must match what git worktree list reports
+	worktreePath, err = filepath.Abs(worktreePath)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create parent directory if needed
 	if err := os.MkdirAll(filepath.Dir(worktreePath), 0755); err != nil {
@@ -105,20 +142,34 @@ func (m *Manager) Create(name string, opts CreateOptions) (*Session, error) {
 		return nil, fmt.Errorf("session %q already exists\n\nUse 'ccs switch %s' to switch to it, or 'ccs finish %s --delete' to remove it", name, name, name)
 	}
 
-	// Create worktree with new branch
-	if err := m.git.WorktreeAdd(worktreePath, branchName, baseBranch); err != nil {
-		return nil, fmt.Errorf("could not create worktree: %w", err)
-	}
-
 	session := &Session{
 		Name:       name,
 		Path:       worktreePath,
 		Branch:     branchName,
 		BaseBranch: baseBranch,
 		BaseCommit: baseCommit,
+		Parent:     opts.FromSession,
 		RepoRoot:   m.git.RepoRoot(),
 	}
 
+	// Runs in the repository root, as the worktree doesn't exist yet
+	if err := m.runHook(HookPreCreate, session, ""); err != nil {
+		return nil, err
+	}
+
+	// Create worktree with new branch
+	if err := m.git.WorktreeAdd(worktreePath, branchName, startPoint); err != nil {
+		return nil, fmt.Errorf("could not create worktree: %w", err)
+	}
+
+	// Record the base in the branch config so it survives state loss
+	m.git.ConfigSet(baseConfigKey(branchName), baseBranch)
+	m.git.ConfigSet(baseCommitConfigKey(branchName), baseCommit)
+
+	if err := m.copyLocalFiles(worktreePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	// Save to global state
 	if m.state != nil {
 		m.state.AddSession(state.SessionState{
@@ -128,21 +179,36 @@ func (m *Manager) Create(name string, opts CreateOptions) (*Session, error) {
 			WorkTree:   worktreePath,
 			Branch:     branchName,
 			BaseBranch: baseBranch,
+			BaseCommit: baseCommit,
+			Parent:     opts.FromSession,
 			CreatedAt:  time.Now(),
 			LastAccess: time.Now(),
 		})
 	}
 
-	// Run post-create hook
-	if m.cfg.Hooks.PostCreate != "" {
-		if err := m.runHook(m.cfg.Hooks.PostCreate, worktreePath); err != nil {
-			// Clean up on hook failure
-			m.git.WorktreeRemove(worktreePath, true)
-			m.git.BranchDelete(branchName, true)
-			return nil, fmt.Errorf("post_create hook failed: %w", err)
+	if err := m.writeEnvFile(session); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write %s/env: %v\n", envDir, err)
+	}
+
+	if opts.prepare != nil {
+		if err := opts.prepare(session); err != nil {
+			m.deleteSession(session, true, true)
+			return nil, err
 		}
 	}
 
+	if err := m.runHook(HookPostCreate, session, ""); err != nil {
+		// Clean up on hook failure
+		m.deleteSession(session, true, true)
+		return nil, err
+	}
+
+	detail := "from " + baseBranch
+	if opts.forkOf != "" {
+		detail = "fork of " + opts.forkOf
+	}
+	m.recordEvent(session, state.EventCreated, detail, baseCommit)
+
 	// Create terminal window
 	if !opts.NoTerminal && m.terminal.Name() != "none" {
 		// Pass claude command - terminal handles shell wrapping
@@ -153,7 +219,7 @@ func (m *Manager) Create(name string, opts CreateOptions) (*Session, error) {
 				startCmd += " " + strings.Join(opts.ClaudeArgs, " ")
 			}
 		}
-		if err := m.terminal.CreateWindow(name, worktreePath, startCmd); err != nil {
+		if err := m.terminal.CreateWindow(name, worktreePath, startCmd, m.sessionEnv(session)); err != nil {
 			// Non-fatal, just warn
 			fmt.Fprintf(os.Stderr, "Warning: could not create terminal window: %v\n", err)
 		}
@@ -161,6 +227,7 @@ func (m *Manager) Create(name string, opts CreateOptions) (*Session, error) {
 		// Start claude in current terminal
 		cmd := exec.Command("claude", opts.ClaudeArgs...)
 		cmd.Dir = worktreePath
+		cmd.Env = append(os.Environ(), m.sessionEnv(session)...)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -175,11 +242,18 @@ func (m *Manager) Create(name string, opts CreateOptions) (*Session, error) {
 
 // CreateOptions contains options for session creation
 type CreateOptions struct {
-	From       string   // Base branch/commit
-	Here       bool     // Create in ./.worktrees/
-	NoClaude   bool     // Don't start Claude
-	NoTerminal bool     // Don't create terminal window
-	ClaudeArgs []string // Arguments to pass to Claude
+	From        string   // Base branch/commit
+	FromSession string   // Parent session to stack on
+	Here        bool     // Create in ./.worktrees/
+	NoClaude    bool     // Don't start Claude
+	NoTerminal  bool     // Don't create terminal window
+	ClaudeArgs  []string // Arguments to pass to Claude
+
+	// Set by Fork to start a session from another one's work
+	startPoint string               // Commit to branch from instead of the base
+	baseCommit string               // Hint for where startPoint diverged from the base
+	forkOf     string               // Session being forked
+	prepare    func(*Session) error // Run once the worktree exists
 }
 
 // List lists all sessions for the current repository
@@ -192,6 +266,9 @@ func (m *Manager) List() ([]*Session, error) {
 	var sessions []*Session
 	prefix := m.cfg.BranchPrefix
 
+	// Read all recorded bases with a single git call
+	bases, _ := m.git.ConfigGetRegexp(`^branch\..*\.ccsbase(commit)?$`)
+
 	for _, wt := range worktrees {
 		if wt.Bare {
 			continue
@@ -201,17 +278,86 @@ func (m *Manager) List() ([]*Session, error) {
 		}
 
 		name := strings.TrimPrefix(wt.Branch, prefix)
-		sessions = append(sessions, &Session{
-			Name:     name,
-			Path:     wt.Path,
-			Branch:   wt.Branch,
-			RepoRoot: m.git.RepoRoot(),
-		})
+		session := &Session{
+			Name:       name,
+			Path:       wt.Path,
+			Branch:     wt.Branch,
+			BaseBranch: bases[baseConfigKey(wt.Branch)],
+			BaseCommit: bases[baseCommitConfigKey(wt.Branch)],
+			RepoRoot:   m.git.RepoRoot(),
+		}
+		m.hydrateBase(session)
+		sessions = append(sessions, session)
 	}
 
+	linkParents(sessions)
+
 	return sessions, nil
 }
 
+// hydrateBase fills in a session's base from global state when the branch
+// config has none (e.g. sessions created by older versions), falling back
+// to the configured default base
+func (m *Manager) hydrateBase(session *Session) {
+	if session.BaseBranch == "" || session.BaseCommit == "" {
+		if m.state != nil {
+			if st := m.state.GetSession(session.Path); st != nil {
+				if session.BaseBranch == "" {
+					session.BaseBranch = st.BaseBranch
+				}
+				if session.BaseCommit == "" {
+					session.BaseCommit = st.BaseCommit
+				}
+			}
+		}
+	}
+	if session.BaseBranch == "" {
+		session.BaseBranch = m.cfg.DefaultBase
+	}
+}
+
+// MergeBase returns the commit a session diverged from its base at. If the
+// base ref no longer resolves, the base commit recorded at creation is used.
+func (m *Manager) MergeBase(session *Session) string {
+	wtGit := m.git.InWorktree(session.Path)
+	if mergeBase, err := m.forkPoint(wtGit, session.BaseBranch, "HEAD", session.BaseCommit); err == nil {
+		return mergeBase
+	}
+	if session.BaseCommit != "" {
+		return session.BaseCommit
+	}
+	return session.BaseBranch
+}
+
+// forkPoint returns where head's own commits start relative to base. That is
+// normally their merge base, but a session restacked after the session it
+// was stacked on was squashed into the base still carries that session's
+// commits; its recorded base commit marks where its own commits begin.
+func (m *Manager) forkPoint(g git.Git, base, head, baseCommit string) (string, error) {
+	mergeBase, err := g.MergeBase(base, head)
+	if err != nil {
+		return "", err
+	}
+	if baseCommit == "" || baseCommit == mergeBase {
+		return mergeBase, nil
+	}
+	if ok, _ := g.IsAncestor(mergeBase, baseCommit); !ok {
+		return mergeBase, nil
+	}
+	if ok, _ := g.IsAncestor(baseCommit, head); !ok {
+		return mergeBase, nil
+	}
+	return baseCommit, nil
+}
+
+func baseConfigKey(branch string) string {
+	return "branch." + branch + ".ccsbase"
+}
+
+func baseCommitConfigKey(branch string) string {
+	return "branch." + branch + ".ccsbasecommit"
+}
+
 // Get gets a session by name
 func (m *Manager) Get(name string) (*Session, error) {
 	sessions, err := m.List()
@@ -256,11 +402,8 @@ func (m *Manager) GetStatus(session *Session) (*Status, error) {
 	// Get files changed and commits ahead
 	wtGit := m.git.InWorktree(session.Path)
 
-	// Find merge base with main branch
-	mergeBase, err := wtGit.MergeBase(m.cfg.DefaultBase, "HEAD")
-	if err != nil {
-		mergeBase = m.cfg.DefaultBase
-	}
+	// Find merge base with the session's base
+	mergeBase := m.MergeBase(session)
 
 	diffStat, err := wtGit.DiffStat(mergeBase, "HEAD")
 	if err == nil {
@@ -273,7 +416,7 @@ func (m *Manager) GetStatus(session *Session) (*Status, error) {
 	}
 
 	// Get Claude state
-	status.ClaudeState = claude.GetState(session.Path)
+	status.ClaudeState = m.processes().State(session.Path)
 
 	// Get terminal info
 	if m.terminal.Name() != "none" {
@@ -289,6 +432,32 @@ func (m *Manager) GetStatus(session *Session) (*Status, error) {
 	return status, nil
 }
 
+// GetClaudeInfo returns Claude process state and transcript usage for a
+// session. Parsing transcripts is comparatively slow, so this is separate
+// from GetStatus.
+func (m *Manager) GetClaudeInfo(session *Session) *claude.Info {
+	return m.processes().Info(session.Path, m.cfg.Claude.Pricing)
+}
+
+// processes returns a snapshot of running Claude processes, scanning once
+// per manager so listing many sessions costs a single scan
+func (m *Manager) processes() *claude.ProcessTable {
+	if m.procs == nil {
+		m.procs, _ = claude.ScanProcesses()
+	}
+	return m.procs
+}
+
+// checkClaudeStopped refuses to change a session's worktree from under
+// Claude while it is running or waiting in it
+func (m *Manager) checkClaudeStopped(session *Session) error {
+	st := m.processes().State(session.Path)
+	if st == claude.StateRunning || st == claude.StateWaiting {
+		return fmt.Errorf("Claude is running in %s\n\nStop it first with 'ccs pause %s'", session.Name, session.Name)
+	}
+	return nil
+}
+
 // Switch switches to a session
 func (m *Manager) Switch(name string) error {
 	session, err := m.Get(name)
@@ -296,151 +465,157 @@ func (m *Manager) Switch(name string) error {
 		return err
 	}
 
-	// If in a terminal, switch window
-	if m.terminal.Name() != "none" {
-		if err := m.terminal.SwitchWindow(name); err == nil {
-			return nil
-		}
+	if err := m.runHook(HookPreSwitch, session, ""); err != nil {
+		return err
 	}
 
-	// Otherwise, print cd command for shell integration
-	fmt.Printf("cd %s\n", session.Path)
-	return nil
+	m.recordEvent(session, state.EventSwitched, "", "")
+	m.Touch(session)
+
+	// If in a terminal, switch window; otherwise print cd command for shell
+	// integration
+	if m.terminal.Name() == "none" || m.terminal.SwitchWindow(name) != nil {
+		fmt.Printf("cd %s\n", session.Path)
+	}
+	return m.runHook(HookPostSwitch, session, "")
 }
 
-// Delete deletes a session
-func (m *Manager) Delete(name string, force bool) error {
+// Pause stops Claude in a session, keeping the worktree
+func (m *Manager) Pause(name string) error {
 	session, err := m.Get(name)
 	if err != nil {
 		return err
 	}
+	if err := m.runHook(HookPrePause, session, ""); err != nil {
+		return err
+	}
+	if err := claude.StopProcess(session.Path); err != nil {
+		return fmt.Errorf("could not stop Claude: %w", err)
+	}
+	m.recordEvent(session, state.EventPaused, "", "")
+	return nil
+}
 
-	// Stop claude process if running
-	claude.StopProcess(session.Path)
+// Resume restarts Claude with --continue in a new terminal window for a
+// paused session
+func (m *Manager) Resume(name string, claudeArgs []string) error {
+	session, err := m.Get(name)
+	if err != nil {
+		return err
+	}
 
-	// Close terminal window
-	if m.terminal.Name() != "none" {
-		m.terminal.CloseWindow(name)
+	// Check if already running
+	st := m.processes().State(session.Path)
+	if st == claude.StateRunning || st == claude.StateWaiting {
+		return fmt.Errorf("Claude is already running for %s", session.Name)
 	}
 
-	// Remove worktree
-	if err := m.git.WorktreeRemove(session.Path, force); err != nil {
-		return fmt.Errorf("could not remove worktree: %w", err)
+	if m.terminal.Name() == "none" {
+		return fmt.Errorf("no terminal available - switch to session directory and run claude manually")
 	}
 
-	// Delete branch
-	if err := m.git.BranchDelete(session.Branch, force); err != nil {
-		// Non-fatal
-		fmt.Fprintf(os.Stderr, "Warning: could not delete branch %s: %v\n", session.Branch, err)
+	// Build claude command with --continue and any additional args
+	claudeCmd := "claude --continue"
+	if len(claudeArgs) > 0 {
+		claudeCmd += " " + strings.Join(claudeArgs, " ")
 	}
 
-	// Remove from global state
-	if m.state != nil {
-		m.state.RemoveSession(session.Path)
+	// Create terminal window with Claude running in login shell
+	if err := m.createWindow(session, claudeCmd); err != nil {
+		return fmt.Errorf("could not create terminal window: %w", err)
 	}
 
-	return nil
+	m.recordEvent(session, state.EventResumed, "", "")
+	m.Touch(session)
+	return m.runHook(HookPostResume, session, "")
 }
 
-// Finish finishes a session with the given options
-func (m *Manager) Finish(name string, opts FinishOptions) error {
+// Delete deletes a session
+func (m *Manager) Delete(name string, force bool) error {
 	session, err := m.Get(name)
 	if err != nil {
 		return err
 	}
-
-	// Run pre-finish hook
-	if m.cfg.Hooks.PreFinish != "" && !opts.Force {
-		if err := m.runHook(m.cfg.Hooks.PreFinish, session.Path); err != nil {
-			return fmt.Errorf("pre_finish hook failed: %w\nUse --force to skip", err)
-		}
+	if force {
+		err = m.restackChildren(session)
+	} else {
+		err = m.checkNoChildren(session)
 	}
+	if err != nil {
+		return err
+	}
+	if err := m.runHook(HookPreDelete, session, ""); err != nil {
+		return err
+	}
+	if err := m.deleteSession(session, force, force); err != nil {
+		return err
+	}
+	m.recordEvent(session, state.EventDeleted, "", "")
+	return m.runHook(HookPostDelete, session, "")
+}
 
-	switch {
-	case opts.Delete:
-		return m.Delete(name, opts.Force)
-
-	case opts.PR:
-		// Push branch for PR
-		wtGit := m.git.InWorktree(session.Path)
-		if err := wtGit.Push(session.Branch, false); err != nil {
-			return fmt.Errorf("could not push branch: %w", err)
-		}
-		// Don't delete branch or worktree when creating PR
-		fmt.Printf("Branch %s pushed. Create a PR at your repository.\n", session.Branch)
-
-		// Stop claude process
-		claude.StopProcess(session.Path)
-
-		// Close terminal window
-		if m.terminal.Name() != "none" {
-			m.terminal.CloseWindow(name)
-		}
-
-		// Remove worktree but keep branch
-		return m.git.WorktreeRemove(session.Path, opts.Force)
-
-	case opts.Squash:
-		// Checkout base, squash merge, cleanup
-		return m.mergeSession(session, true, opts.Force)
+// deleteSession removes a session's worktree, branch, window, checkpoints
+// and state. The branch is force-deleted when forceBranch is set, e.g. after
+// it has been squashed into a base branch that git cannot see it merged into.
+func (m *Manager) deleteSession(session *Session, force, forceBranch bool) error {
+	if err := m.removeWorktree(session, force, forceBranch); err != nil {
+		return err
+	}
 
-	case opts.Merge:
-		return m.mergeSession(session, false, opts.Force)
+	m.deleteCheckpoints(session.Name)
 
-	default:
-		return fmt.Errorf("no finish action specified")
+	// Remove from global state
+	if m.state != nil {
+		m.state.RemoveSession(session.Path)
 	}
-}
 
-// FinishOptions contains options for finishing a session
-type FinishOptions struct {
-	Squash bool
-	Merge  bool
-	PR     bool
-	Delete bool
-	Force  bool
+	return nil
 }
 
-func (m *Manager) mergeSession(session *Session, squash, force bool) error {
-	// Get commit count for message
-	mergeBase, _ := m.git.MergeBase(m.cfg.DefaultBase, session.Branch)
-	commitCount, _ := m.git.CommitCount(mergeBase, session.Branch)
+// removeWorktree stops Claude and removes a session's window, worktree and
+// branch
+func (m *Manager) removeWorktree(session *Session, force, forceBranch bool) error {
+	// Stop claude process if running
+	claude.StopProcess(session.Path)
 
-	// Checkout base branch
-	baseGit := m.git // Use main repo git
+	// Close terminal window
+	if m.terminal.Name() != "none" {
+		m.terminal.CloseWindow(session.Name)
+	}
 
-	// First ensure base branch is checked out in main worktree
-	currentBranch, _ := baseGit.BranchCurrent()
-	if currentBranch != m.cfg.DefaultBase {
-		// We're in a worktree, need to use main repo
-		fmt.Printf("Please checkout %s in the main worktree before merging.\n", m.cfg.DefaultBase)
-		return fmt.Errorf("not on base branch")
+	// Remove worktree
+	if err := m.git.WorktreeRemove(session.Path, force); err != nil {
+		return fmt.Errorf("could not remove worktree: %w", err)
 	}
 
-	if squash {
-		if err := baseGit.MergeSquash(session.Branch); err != nil {
-			return fmt.Errorf("squash merge failed: %w", err)
-		}
-		msg := fmt.Sprintf("Squashed %d commits from %s", commitCount, session.Name)
-		if err := baseGit.Commit(msg); err != nil {
-			return fmt.Errorf("commit failed: %w", err)
-		}
-	} else {
-		if err := baseGit.Merge(session.Branch, false); err != nil {
-			return fmt.Errorf("merge failed: %w", err)
-		}
+	// Delete branch
+	if err := m.git.BranchDelete(session.Branch, forceBranch); err != nil {
+		// Non-fatal
+		fmt.Fprintf(os.Stderr, "Warning: could not delete branch %s: %v\n", session.Branch, err)
 	}
 
-	// Cleanup
-	return m.Delete(session.Name, force)
+	return nil
 }
 
-func (m *Manager) runHook(command, dir string) error {
-	cmd := exec.Command("sh", "-c", command)
-	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// recordEvent adds an event to the session history. History is best-effort
+// and never fails the operation being recorded.
+func (m *Manager) recordEvent(session *Session, eventType, detail, commit string) {
+	if m.state == nil {
+		return
+	}
+	err := m.state.RecordEvent(state.Event{
+		Time:     time.Now(),
+		Type:     eventType,
+		Session:  session.Name,
+		RepoPath: m.git.RepoRoot(),
+		RepoName: m.git.RepoName(),
+		Branch:   session.Branch,
+		Detail:   detail,
+		Commit:   commit,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record %s event: %v\n", eventType, err)
+	}
 }
 
 // Error types
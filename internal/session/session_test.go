@@ -1,6 +1,17 @@
 package session
 
-import "testing"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emaland/ccs/internal/config"
+	"github.com/emaland/ccs/internal/git"
+	"github.com/emaland/ccs/internal/terminal"
+)
 
 func TestValidateName(t *testing.T) {
 	tests := []struct {
@@ -26,3 +37,239 @@ func TestValidateName(t *testing.T) {
 		}
 	}
 }
+
+func TestStackOrder(t *testing.T) {
+	sessions := []*Session{
+		{Name: "part2", Branch: "ccs/part2", BaseBranch: "ccs/part1"},
+		{Name: "other", Branch: "ccs/other", BaseBranch: "main"},
+		{Name: "part3", Branch: "ccs/part3", BaseBranch: "ccs/part2"},
+		{Name: "part1", Branch: "ccs/part1", BaseBranch: "main"},
+		{Name: "orphan", Branch: "ccs/orphan", BaseBranch: "ccs/gone"},
+	}
+	linkParents(sessions)
+
+	ordered, depths := StackOrder(sessions)
+
+	want := []struct {
+		name  string
+		depth int
+	}{
+		{"other", 0},
+		{"part1", 0},
+		{"part2", 1},
+		{"part3", 2},
+		{"orphan", 0},
+	}
+	if len(ordered) != len(want) {
+		t.Fatalf("expected %d sessions, got %d", len(want), len(ordered))
+	}
+	for i, w := range want {
+		if ordered[i].Name != w.name || depths[i] != w.depth {
+			t.Errorf("position %d: expected %s at depth %d, got %s at depth %d",
+				i, w.name, w.depth, ordered[i].Name, depths[i])
+		}
+	}
+	if sessions[0].Parent != "part1" {
+		t.Errorf("expected part2 to be stacked on part1, got %q", sessions[0].Parent)
+	}
+}
+
+func TestForkNames(t *testing.T) {
+	tests := []struct {
+		count int
+		want  []string
+	}{
+		{0, []string{"try"}},
+		{1, []string{"try"}},
+		{3, []string{"try-1", "try-2", "try-3"}},
+	}
+
+	for _, tt := range tests {
+		if got := forkNames("try", tt.count); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("forkNames(%q, %d) = %v, want %v", "try", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestRankComparisons(t *testing.T) {
+	pass := TestResult{Passed: true}
+	fail := TestResult{}
+	results := []*Comparison{
+		{Name: "big", Insertions: 100, Tests: []TestResult{pass, pass}},
+		{Name: "failing", Insertions: 1, Tests: []TestResult{pass, fail}},
+		{Name: "small", Insertions: 5, Deletions: 5, Tests: []TestResult{pass, pass}, Cost: 2},
+		{Name: "small-cheap", Insertions: 10, Tests: []TestResult{pass, pass}, Cost: 1},
+	}
+
+	rankComparisons(results)
+
+	var got []string
+	for _, c := range results {
+		got = append(got, c.Name)
+	}
+	want := []string{"small-cheap", "small", "big", "failing"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rankComparisons order = %v, want %v", got, want)
+	}
+}
+
+func TestGCDecision(t *testing.T) {
+	day := 24 * time.Hour
+	policy := config.GCConfig{ArchiveAfterDays: 14, DeleteMergedAfterDays: 3}
+
+	tests := []struct {
+		name   string
+		policy config.GCConfig
+		idle   time.Duration
+		merged bool
+		clean  bool
+		want   string
+	}{
+		{"recently used", policy, 2 * day, false, true, ""},
+		{"idle", policy, 20 * day, false, true, GCArchive},
+		{"idle and dirty", policy, 20 * day, false, false, GCArchive},
+		{"merged", policy, 4 * day, true, true, GCDelete},
+		{"merged recently", policy, 2 * day, true, true, ""},
+		{"merged but dirty", policy, 4 * day, true, false, ""},
+		{"merged, dirty and idle", policy, 20 * day, true, false, GCArchive},
+		{"archiving disabled", config.GCConfig{DeleteMergedAfterDays: 3}, 20 * day, false, true, ""},
+		{"deleting disabled", config.GCConfig{ArchiveAfterDays: 14}, 20 * day, true, true, GCArchive},
+	}
+
+	for _, tt := range tests {
+		got, _ := gcDecision(tt.policy, tt.idle, tt.merged, tt.clean, "main")
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCopyLocalFiles(t *testing.T) {
+	root := t.TempDir()
+	worktree := t.TempDir()
+	for _, name := range []string{".env", "config/a.local", "config/b.local", "node_modules/x/index.js", ".cache/data", ".git/config", "tracked"} {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(worktree, "tracked"), []byte("checked out"), 0644)
+
+	g, _ := git.NewExecGit(root)
+	m := &Manager{cfg: &config.Config{Worktree: config.WorktreeConfig{
+		Copy:    []string{".env", "config/*.local", ".git*", "tracked", "missing"},
+		Reflink: []string{"node_modules", ".env"},
+		Symlink: []string{".cache"},
+	}}, git: g}
+
+	if err := m.copyLocalFiles(worktree); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{".env", "config/a.local", "config/b.local", "node_modules/x/index.js", ".cache/data"} {
+		data, err := os.ReadFile(filepath.Join(worktree, name))
+		if err != nil || string(data) != name {
+			t.Errorf("%s: expected %q, got %q (%v)", name, name, data, err)
+		}
+	}
+	if info, err := os.Lstat(filepath.Join(worktree, ".cache")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf(".cache: expected a symlink")
+	}
+	if info, err := os.Lstat(filepath.Join(worktree, ".env")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf(".env: expected a copy")
+	}
+	if _, err := os.Stat(filepath.Join(worktree, ".git")); err == nil {
+		t.Errorf(".git: expected it not to be copied")
+	}
+	if data, _ := os.ReadFile(filepath.Join(worktree, "tracked")); string(data) != "checked out" {
+		t.Errorf("tracked: expected existing file to be kept, got %q", data)
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	dir := t.TempDir()
+	g, _ := git.NewExecGit(dir)
+	session := &Session{Name: "auth", Path: dir, Branch: "ccs/auth", BaseBranch: "main"}
+	out := filepath.Join(dir, "out")
+
+	tests := []struct {
+		name    string
+		hook    config.Hook
+		hookFor string
+		want    string
+		wantErr bool
+	}{
+		{"environment", config.Hook{Commands: []string{`echo "$CCS_HOOK $CCS_SESSION_NAME $CCS_BRANCH $CCS_BASE $CCS_FINISH_STRATEGY" > out`}},
+			HookPreFinish, "pre_finish auth ccs/auth main squash\n", false},
+		{"commands in order", config.Hook{Commands: []string{"echo 1 > out", "echo 2 >> out"}},
+			HookPreFinish, "1\n2\n", false},
+		{"pre hooks fail", config.Hook{Commands: []string{"exit 1", "echo ran > out"}},
+			HookPreFinish, "", true},
+		{"post hooks warn", config.Hook{Commands: []string{"exit 1", "echo ran > out"}},
+			HookPostFinish, "ran\n", false},
+		{"post_create fails", config.Hook{Commands: []string{"exit 1"}},
+			HookPostCreate, "", true},
+		{"warn overrides", config.Hook{Commands: []string{"exit 1"}, OnFailure: config.HookWarn},
+			HookPreFinish, "", false},
+		{"fail overrides", config.Hook{Commands: []string{"exit 1"}, OnFailure: config.HookFail},
+			HookPostFinish, "", true},
+		{"timeout", config.Hook{Commands: []string{"sleep 5"}, Timeout: 50 * time.Millisecond},
+			HookPreFinish, "", true},
+	}
+
+	for _, tt := range tests {
+		os.Remove(out)
+		cfg := &config.Config{}
+		switch tt.hookFor {
+		case HookPreFinish:
+			cfg.Hooks.PreFinish = tt.hook
+		case HookPostFinish:
+			cfg.Hooks.PostFinish = tt.hook
+		case HookPostCreate:
+			cfg.Hooks.PostCreate = tt.hook
+		}
+		m := &Manager{cfg: cfg, git: g}
+
+		err := m.runHook(tt.hookFor, session, "squash")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		got, _ := os.ReadFile(out)
+		if string(got) != tt.want {
+			t.Errorf("%s: expected output %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCreateRequiresBranchBase(t *testing.T) {
+	dir, g := newTestRepo(t)
+	gitRun(t, dir, "tag", "v1")
+	head := gitRun(t, dir, "rev-parse", "HEAD")
+
+	cfg := &config.Config{BranchPrefix: "ccs/", DefaultBase: "main", WorktreeRoot: t.TempDir()}
+	m := NewManager(cfg, g, &terminal.NoopTerminal{}, nil)
+
+	tests := []struct {
+		from    string
+		base    string
+		wantErr bool
+	}{
+		{"main", "main", false},
+		{"refs/heads/main", "main", false},
+		{head, "", true},
+		{"v1", "", true},
+		{"missing", "", true},
+	}
+
+	for i, tt := range tests {
+		sess, err := m.Create(fmt.Sprintf("s%d", i), CreateOptions{From: tt.from, NoClaude: true, NoTerminal: true})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("--from %s: error = %v, wantErr %v", tt.from, err, tt.wantErr)
+			continue
+		}
+		if err == nil && sess.BaseBranch != tt.base {
+			t.Errorf("--from %s: expected base %s, got %s", tt.from, tt.base, sess.BaseBranch)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// linkParents sets Parent on every session whose base branch is another
+// session's branch
+func linkParents(sessions []*Session) {
+	byBranch := make(map[string]*Session, len(sessions))
+	for _, s := range sessions {
+		byBranch[s.Branch] = s
+	}
+	for _, s := range sessions {
+		if parent, ok := byBranch[s.BaseBranch]; ok && parent != s {
+			s.Parent = parent.Name
+		}
+	}
+}
+
+// StackOrder orders sessions so that each is directly followed by the
+// sessions stacked on it, and returns the depth of each in its stack
+func StackOrder(sessions []*Session) ([]*Session, []int) {
+	names := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		names[s.Name] = true
+	}
+	children := map[string][]*Session{}
+	var roots []*Session
+	for _, s := range sessions {
+		if s.Parent != "" && names[s.Parent] {
+			children[s.Parent] = append(children[s.Parent], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+
+	var ordered []*Session
+	var depths []int
+	seen := map[string]bool{}
+	var walk func(s *Session, depth int)
+	walk = func(s *Session, depth int) {
+		if seen[s.Name] {
+			return
+		}
+		seen[s.Name] = true
+		ordered = append(ordered, s)
+		depths = append(depths, depth)
+		for _, c := range children[s.Name] {
+			walk(c, depth+1)
+		}
+	}
+	for _, s := range roots {
+		walk(s, 0)
+	}
+	return ordered, depths
+}
+
+// StackFrom returns a session followed by every session stacked on it,
+// directly or indirectly, parents before children
+func (m *Manager) StackFrom(name string) ([]*Session, error) {
+	sessions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	ordered, depths := StackOrder(sessions)
+	for i, s := range ordered {
+		if s.Name != name {
+			continue
+		}
+		stack := []*Session{s}
+		for j := i + 1; j < len(ordered) && depths[j] > depths[i]; j++ {
+			stack = append(stack, ordered[j])
+		}
+		return stack, nil
+	}
+	return nil, &ErrSessionNotFound{Name: name}
+}
+
+// Stack returns every session in the stack a session belongs to, from the
+// bottom of the stack up
+func (m *Manager) Stack(name string) ([]*Session, error) {
+	sessions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*Session, len(sessions))
+	for _, s := range sessions {
+		byName[s.Name] = s
+	}
+
+	root, ok := byName[name]
+	if !ok {
+		return nil, &ErrSessionNotFound{Name: name}
+	}
+	for seen := map[string]bool{}; root.Parent != "" && !seen[root.Name]; {
+		seen[root.Name] = true
+		parent, ok := byName[root.Parent]
+		if !ok {
+			break
+		}
+		root = parent
+	}
+	return m.StackFrom(root.Name)
+}
+
+// children returns the sessions stacked directly on a session
+func (m *Manager) children(session *Session) ([]*Session, error) {
+	sessions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	var children []*Session
+	for _, s := range sessions {
+		if s.Parent == session.Name {
+			children = append(children, s)
+		}
+	}
+	return children, nil
+}
+
+// restackChildren moves the sessions stacked on a session onto that
+// session's base, once it has been finished into it or deleted. Their base
+// commits are kept, so the next sync replays only their own commits.
+func (m *Manager) restackChildren(session *Session) error {
+	children, err := m.children(session)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := m.setBase(child, session.BaseBranch, child.BaseCommit, session.Parent); err != nil {
+			return err
+		}
+		fmt.Printf("%s is now based on %s; run 'ccs sync --stack %s' to update it\n", child.Name, session.BaseBranch, child.Name)
+	}
+	return nil
+}
+
+// checkNoChildren refuses to remove a session that other sessions are
+// stacked on
+func (m *Manager) checkNoChildren(session *Session) error {
+	children, err := m.children(session)
+	if err != nil {
+		return err
+	}
+	if len(children) == 0 {
+		return nil
+	}
+	names := make([]string, len(children))
+	for i, c := range children {
+		names[i] = c.Name
+	}
+	return fmt.Errorf("sessions are stacked on %s: %s\nFinish or delete them first, or use --force to move them onto %s",
+		session.Name, strings.Join(names, ", "), session.BaseBranch)
+}
+
+// setBase records a new base for a session in its branch config and in
+// global state
+func (m *Manager) setBase(session *Session, base, baseCommit, parent string) error {
+	if err := m.git.ConfigSet(baseConfigKey(session.Branch), base); err != nil {
+		return fmt.Errorf("could not record base of %s: %w", session.Name, err)
+	}
+	if baseCommit != "" {
+		if err := m.git.ConfigSet(baseCommitConfigKey(session.Branch), baseCommit); err != nil {
+			return fmt.Errorf("could not record base of %s: %w", session.Name, err)
+		}
+	}
+	session.BaseBranch = base
+	session.BaseCommit = baseCommit
+	session.Parent = parent
+
+	if m.state != nil {
+		if st := m.state.GetSession(session.Path); st != nil {
+			st.BaseBranch = base
+			st.BaseCommit = baseCommit
+			st.Parent = parent
+			if err := m.state.AddSession(*st); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not save state: %v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+// pushStack pushes every branch in a stack and records the branch each one
+// should be reviewed against, which gh uses as the default PR base
+func (m *Manager) pushStack(stack []*Session) error {
+	for _, s := range stack {
+		if err := m.git.Push(s.Branch, false); err != nil {
+			return fmt.Errorf("could not push %s: %w", s.Branch, err)
+		}
+		m.git.ConfigSet("branch."+s.Branch+".gh-merge-base", s.BaseBranch)
+		fmt.Printf("Pushed %s (base: %s)\n", s.Branch, s.BaseBranch)
+	}
+	return nil
+}
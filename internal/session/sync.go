@@ -0,0 +1,182 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emaland/ccs/internal/claude"
+	"github.com/emaland/ccs/internal/state"
+)
+
+// Sync strategies
+const (
+	SyncRebase = "rebase"
+	SyncMerge  = "merge"
+)
+
+// SyncOptions contains options for syncing a session with its base
+type SyncOptions struct {
+	Strategy string // SyncRebase or SyncMerge
+	// ResolveWithClaude leaves a conflicted rebase or merge in progress and
+	// starts Claude in the session to resolve it, instead of aborting
+	ResolveWithClaude bool
+}
+
+// SyncResult describes the outcome of syncing one session
+type SyncResult struct {
+	Session        string
+	Onto           string // Ref the session was rebased onto or merged from
+	Strategy       string
+	UpToDate       bool
+	Paused         bool // Claude was running and was stopped for the update
+	Conflicts      []string
+	HandedToClaude bool
+}
+
+// Sync brings a session up to date with its base branch by rebasing onto
+// it or merging it in. If the base has an upstream that is ahead, the
+// upstream is used so that a preceding fetch takes effect.
+func (m *Manager) Sync(name string, opts SyncOptions) (*SyncResult, error) {
+	session, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = m.cfg.Sync.Strategy
+	}
+	if strategy != SyncRebase && strategy != SyncMerge {
+		return nil, fmt.Errorf("unknown sync strategy %q (supported: rebase, merge)", strategy)
+	}
+
+	result := &SyncResult{
+		Session:  session.Name,
+		Onto:     m.syncTarget(m.baseBranch(session)),
+		Strategy: strategy,
+	}
+
+	upToDate, err := m.git.IsAncestor(result.Onto, session.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if upToDate {
+		result.UpToDate = true
+		return result, nil
+	}
+
+	wtGit := m.git.InWorktree(session.Path)
+
+	// Claude would be editing files while they are stashed and rewritten,
+	// or could change the worktree after it was checked
+	if m.processes().PID(session.Path) != 0 {
+		if err := claude.StopProcess(session.Path); err != nil {
+			return nil, fmt.Errorf("could not pause Claude: %w", err)
+		}
+		m.recordEvent(session, state.EventPaused, "for sync", "")
+		result.Paused = true
+	}
+
+	if strategy == SyncRebase {
+		err = m.rebaseSync(session, result.Onto)
+	} else {
+		err = wtGit.MergeAutostash(result.Onto)
+	}
+	if err == nil {
+		// Later syncs and diffs start from the commit synced with
+		if onto, err := m.git.ResolveRef(result.Onto); err == nil {
+			m.setBase(session, session.BaseBranch, onto, session.Parent)
+		}
+		head, _ := m.git.ResolveRef(session.Branch)
+		m.recordEvent(session, state.EventSynced, strategy+" "+result.Onto, head)
+		return result, nil
+	}
+
+	conflicts, _ := wtGit.ConflictedFiles()
+	if len(conflicts) == 0 {
+		m.abortSync(session, strategy)
+		return nil, fmt.Errorf("%s failed: %w", strategy, err)
+	}
+	result.Conflicts = conflicts
+
+	if opts.ResolveWithClaude {
+		if err := m.handConflictToClaude(session, result); err != nil {
+			m.abortSync(session, strategy)
+			return result, err
+		}
+		result.HandedToClaude = true
+		return result, nil
+	}
+
+	m.abortSync(session, strategy)
+	return result, nil
+}
+
+// syncTarget returns the ref to sync onto: the base's upstream if it has
+// one that contains the local base, otherwise the local base
+func (m *Manager) syncTarget(base string) string {
+	upstream, err := m.git.Upstream(base)
+	if err != nil || upstream == "" {
+		return base
+	}
+	if ok, err := m.git.IsAncestor(base, upstream); err == nil && ok {
+		return upstream
+	}
+	return base
+}
+
+// rebaseSync rebases a session onto onto. Only the session's own commits are
+// replayed, so a session stacked on one that was rewritten or squashed does
+// not carry the old copies of its parent's commits along.
+func (m *Manager) rebaseSync(session *Session, onto string) error {
+	wtGit := m.git.InWorktree(session.Path)
+	mergeBase, err := m.git.MergeBase(onto, session.Branch)
+	if err != nil {
+		return err
+	}
+	forkPoint, err := m.forkPoint(m.git, onto, session.Branch, session.BaseCommit)
+	if err != nil {
+		return err
+	}
+	if forkPoint == mergeBase {
+		return wtGit.Rebase(onto)
+	}
+	return wtGit.RebaseOnto(onto, forkPoint)
+}
+
+func (m *Manager) abortSync(session *Session, strategy string) {
+	wtGit := m.git.InWorktree(session.Path)
+	if strategy == SyncRebase {
+		wtGit.RebaseAbort()
+	} else {
+		wtGit.MergeAbort()
+	}
+}
+
+// handConflictToClaude restarts Claude in the session's window with a
+// prompt asking it to resolve the in-progress conflict
+func (m *Manager) handConflictToClaude(session *Session, result *SyncResult) error {
+	if m.terminal.Name() == "none" {
+		return fmt.Errorf("no terminal available to start Claude")
+	}
+
+	if m.processes().PID(session.Path) != 0 {
+		claude.StopProcess(session.Path)
+		result.Paused = true
+	}
+
+	next := "git rebase --continue"
+	if result.Strategy == SyncMerge {
+		next = "git commit --no-edit"
+	}
+	prompt := fmt.Sprintf("A %s onto %s stopped with conflicts in: %s. Resolve the conflicts, stage the files, then run %s.",
+		result.Strategy, result.Onto, strings.Join(result.Conflicts, ", "), next)
+
+	m.terminal.CloseWindow(session.Name)
+	return m.createWindow(session, "claude --continue "+shellQuote(prompt))
+}
+
+// shellQuote quotes s for use as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// appendEvent adds an event to a JSONL log. Each event is written with a
+// single append, which concurrent ccs processes can't interleave.
+func appendEvent(path string, ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readEvents returns the events in a JSONL log that match filter, in the
+// order they were written. Lines that can't be parsed are skipped.
+func readEvents(path string, filter EventFilter) ([]Event, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var ev Event
+		if json.Unmarshal(scanner.Bytes(), &ev) != nil {
+			continue
+		}
+		if filter.Match(ev) {
+			events = append(events, ev)
+		}
+	}
+	return events, scanner.Err()
+}
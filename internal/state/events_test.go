@@ -0,0 +1,55 @@
+package state
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestJSONEventLog(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Time: day, Type: EventCreated, Session: "a", RepoPath: "/src/app", RepoName: "app"},
+		{Time: day.Add(time.Hour), Type: EventFinished, Session: "a", RepoPath: "/src/app", RepoName: "app", Detail: "squash", Commit: "abc123"},
+		{Time: day.Add(2 * time.Hour), Type: EventDeleted, Session: "b", RepoPath: "/src/site", RepoName: "site"},
+	}
+	for _, ev := range events {
+		if err := m.RecordEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A torn or foreign line doesn't hide the rest of the history
+	f, err := os.OpenFile(m.eventsPath(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{\"type\":\n")
+	f.Close()
+
+	all, err := m.Events(EventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(all))
+	}
+	if all[1] != events[1] {
+		t.Errorf("expected %+v, got %+v", events[1], all[1])
+	}
+
+	got, err := m.Events(EventFilter{RepoName: "app", Since: day.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Type != EventFinished {
+		t.Errorf("expected the finished event, got %+v", got)
+	}
+}
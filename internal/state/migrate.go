@@ -0,0 +1,119 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// CurrentVersion is the state schema version this build reads and writes
+const CurrentVersion = 4
+
+// migrations upgrade the decoded state file one version at a time:
+// migrations[i] takes version i+1 to version i+2. They work on the generic
+// JSON form so they can reshape data the current structs can't represent.
+var migrations = []func(st map[string]interface{}) error{
+	migrateV1,
+	migrateV2,
+	migrateV3,
+}
+
+// ErrNewerVersion is returned when changing a state file written by a newer
+// ccs, which would lose whatever that version added
+type ErrNewerVersion struct {
+	Path    string
+	Version int
+}
+
+func (e *ErrNewerVersion) Error() string {
+	return fmt.Sprintf("%s was written by a newer ccs (state version %d, this build supports %d)\nUpgrade ccs to make changes", e.Path, e.Version, CurrentVersion)
+}
+
+// decodeState parses a state file of any version up to CurrentVersion,
+// applying migrations as needed, and returns the version found on disk.
+// Files from a newer version are decoded as far as the current structs go.
+func decodeState(data []byte) (GlobalState, int, error) {
+	var st GlobalState
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return st, 0, err
+	}
+
+	version := 1 // Files written before versioning have no version field
+	if v, ok := raw["version"].(float64); ok && v > 0 {
+		version = int(v)
+	}
+
+	if version < CurrentVersion {
+		for v := version; v < CurrentVersion; v++ {
+			if err := migrations[v-1](raw); err != nil {
+				return st, version, fmt.Errorf("migrating state from version %d: %w", v, err)
+			}
+		}
+		raw["version"] = CurrentVersion
+
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return st, version, err
+		}
+	}
+
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, version, err
+	}
+	if st.Sessions == nil {
+		st.Sessions = []SessionState{}
+	}
+	return st, version, nil
+}
+
+// migrateV1 makes worktree paths absolute and drops duplicate entries.
+// Version 1 stored sessions created with --here relative to the repository,
+// and could record the same worktree more than once.
+func migrateV1(st map[string]interface{}) error {
+	sessions, _ := st["sessions"].([]interface{})
+
+	index := map[string]int{}
+	var migrated []interface{}
+	for _, s := range sessions {
+		sess, ok := s.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected session entry %v", s)
+		}
+
+		worktree, _ := sess["worktree"].(string)
+		if repo, _ := sess["repo_path"].(string); worktree != "" && !filepath.IsAbs(worktree) && repo != "" {
+			worktree = filepath.Join(repo, worktree)
+			sess["worktree"] = worktree
+		}
+
+		// The later entry is the more recent one
+		if i, ok := index[worktree]; ok {
+			migrated[i] = sess
+			continue
+		}
+		index[worktree] = len(migrated)
+		migrated = append(migrated, sess)
+	}
+
+	if migrated == nil {
+		migrated = []interface{}{}
+	}
+	st["sessions"] = migrated
+	return nil
+}
+
+// migrateV2 changes nothing. Version 3 added archived sessions, whose
+// worktrees don't exist; the version bump stops older builds, which would
+// clean them up as stale, from changing the file.
+func migrateV2(st map[string]interface{}) error {
+	return nil
+}
+
+// migrateV3 changes nothing. Version 4 added port reservations; the version
+// bump stops older builds, which would drop them on their next write and
+// hand out the same ports twice, from changing the file.
+func migrateV3(st map[string]interface{}) error {
+	return nil
+}
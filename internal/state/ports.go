@@ -0,0 +1,118 @@
+package state
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReservePorts returns the block of ports reserved for the session in
+// worktreePath, reserving count ports at the first free block from start if
+// it has none. The read and the write happen under the state file's lock,
+// so concurrent ccs processes never reserve the same block. Sessions not in
+// the state get no ports.
+func (m *Manager) ReservePorts(worktreePath string, start, count int, free func(port int) bool) (base, n int, err error) {
+	err = m.update(func(st *GlobalState) error {
+		for i := range st.Sessions {
+			sess := &st.Sessions[i]
+			if sess.WorkTree != worktreePath {
+				continue
+			}
+			if sess.PortCount == 0 {
+				b, err := allocatePorts(st.Sessions, start, count, free)
+				if err != nil {
+					return err
+				}
+				sess.PortBase, sess.PortCount = b, count
+			}
+			base, n = sess.PortBase, sess.PortCount
+			return nil
+		}
+		return nil
+	})
+	return base, n, err
+}
+
+// ReservePorts returns the block of ports reserved for the session in
+// worktreePath, reserving one if it has none. The transaction begins with
+// BEGIN IMMEDIATE on its own connection, taking the write lock before the
+// read, so concurrent ccs processes never reserve the same block.
+func (s *SQLiteStore) ReservePorts(worktreePath string, start, count int, free func(port int) bool) (base, n int, err error) {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return 0, 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, `SELECT worktree, port_base, port_count FROM sessions`)
+	if err != nil {
+		return 0, 0, err
+	}
+	var sessions []SessionState
+	found := -1
+	for rows.Next() {
+		var e SessionState
+		if err := rows.Scan(&e.WorkTree, &e.PortBase, &e.PortCount); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		if e.WorkTree == worktreePath {
+			found = len(sessions)
+		}
+		sessions = append(sessions, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	if found < 0 {
+		return 0, 0, nil
+	}
+	sess := sessions[found]
+	if sess.PortCount > 0 {
+		return sess.PortBase, sess.PortCount, nil
+	}
+
+	base, err = allocatePorts(sessions, start, count, free)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := conn.ExecContext(ctx, `UPDATE sessions SET port_base = ?, port_count = ? WHERE worktree = ?`, base, count, worktreePath); err != nil {
+		return 0, 0, err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return 0, 0, err
+	}
+	committed = true
+	return base, count, nil
+}
+
+// allocatePorts finds the first block of count ports from start that
+// doesn't overlap a block reserved by another session and whose first port
+// nothing is listening on
+func allocatePorts(sessions []SessionState, start, count int, free func(port int) bool) (int, error) {
+	for base := start; base+count-1 <= 65535; base += count {
+		taken := false
+		for _, s := range sessions {
+			if s.PortCount > 0 && base < s.PortBase+s.PortCount && s.PortBase < base+count {
+				taken = true
+				break
+			}
+		}
+		if !taken && free(base) {
+			return base, nil
+		}
+	}
+	return 0, fmt.Errorf("no free block of %d ports from %d", count, start)
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestAllocatePorts(t *testing.T) {
+	free := func(int) bool { return true }
+
+	tests := []struct {
+		name     string
+		sessions []SessionState
+		free     func(int) bool
+		want     int
+		wantErr  bool
+	}{
+		{"first", nil, free, 3100, false},
+		{"next free block", []SessionState{{PortBase: 3100, PortCount: 10}, {PortBase: 3110, PortCount: 10}}, free, 3120, false},
+		{"gap", []SessionState{{PortBase: 3110, PortCount: 10}}, free, 3100, false},
+		{"overlapping old block size", []SessionState{{PortBase: 3095, PortCount: 20}}, free, 3120, false},
+		{"sessions without ports", []SessionState{{PortBase: 0, PortCount: 0}}, free, 3100, false},
+		{"port in use", nil, func(p int) bool { return p != 3100 }, 3110, false},
+		{"exhausted", nil, func(int) bool { return false }, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := allocatePorts(tt.sessions, 3100, 10, tt.free)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: expected %d (err %v), got %d (%v)", tt.name, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
+
+func TestConcurrentReservePorts(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	tests := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{"json", func(t *testing.T) Store {
+			m, err := NewManager()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return m
+		}},
+		{"sqlite", func(t *testing.T) Store {
+			s, err := NewSQLiteStore(dbPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+
+			// Separate stores stand in for separate ccs processes
+			const n = 10
+			stores := make([]Store, n)
+			for i := range stores {
+				stores[i] = tt.open(t)
+				defer stores[i].Close()
+				name := fmt.Sprintf("s%d", i)
+				if err := stores[i].AddSession(SessionState{Name: name, WorkTree: "/wt/" + name}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			bases := make([]int, n)
+			var wg sync.WaitGroup
+			for i, s := range stores {
+				wg.Add(1)
+				go func(i int, s Store) {
+					defer wg.Done()
+					base, _, err := s.ReservePorts(fmt.Sprintf("/wt/s%d", i), 3100, 10, func(int) bool { return true })
+					if err != nil {
+						t.Error(err)
+					}
+					bases[i] = base
+				}(i, s)
+			}
+			wg.Wait()
+
+			seen := map[int]bool{}
+			for i, base := range bases {
+				if base == 0 || seen[base] {
+					t.Errorf("s%d got port base %d, already reserved or none", i, base)
+				}
+				seen[base] = true
+			}
+
+			// A session keeps its block
+			if base, count, err := stores[0].ReservePorts("/wt/s0", 3100, 10, nil); err != nil || base != bases[0] || count != 10 {
+				t.Errorf("expected s0 to keep %d, got %d/%d (%v)", bases[0], base, count, err)
+			}
+		})
+	}
+}
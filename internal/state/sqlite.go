@@ -0,0 +1,291 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteVersion is the schema version of state.db, kept in user_version
+const sqliteVersion = 4
+
+// sqliteMigrations upgrade state.db one version at a time:
+// sqliteMigrations[i] takes version i+1 to version i+2
+var sqliteMigrations = []string{
+	`ALTER TABLE events ADD COLUMN commit_id TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE sessions ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
+	 ALTER TABLE sessions ADD COLUMN archive_ref TEXT NOT NULL DEFAULT '';
+	 ALTER TABLE sessions ADD COLUMN transcripts TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE sessions ADD COLUMN port_base INTEGER NOT NULL DEFAULT 0;
+	 ALTER TABLE sessions ADD COLUMN port_count INTEGER NOT NULL DEFAULT 0`,
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	worktree    TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	repo_path   TEXT NOT NULL,
+	repo_name   TEXT NOT NULL,
+	branch      TEXT NOT NULL,
+	base_branch TEXT NOT NULL,
+	base_commit TEXT NOT NULL DEFAULT '',
+	parent      TEXT NOT NULL DEFAULT '',
+	created_at  TEXT NOT NULL,
+	last_access TEXT NOT NULL,
+	archived    INTEGER NOT NULL DEFAULT 0,
+	archive_ref TEXT NOT NULL DEFAULT '',
+	transcripts TEXT NOT NULL DEFAULT '',
+	port_base   INTEGER NOT NULL DEFAULT 0,
+	port_count  INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS events (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	time      TEXT NOT NULL,
+	type      TEXT NOT NULL,
+	session   TEXT NOT NULL,
+	repo_path TEXT NOT NULL,
+	repo_name TEXT NOT NULL,
+	branch    TEXT NOT NULL DEFAULT '',
+	detail    TEXT NOT NULL DEFAULT '',
+	commit_id TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS events_time ON events (time);
+CREATE INDEX IF NOT EXISTS events_session ON events (repo_path, session);
+`
+
+const sessionColumns = `name, repo_path, repo_name, worktree, branch, base_branch, base_commit, parent, created_at, last_access, archived, archive_ref, transcripts, port_base, port_count`
+
+// SQLiteStore keeps state in a SQLite database, along with an append-only
+// history of session events. SQLite handles locking between processes;
+// transactions take the write lock when they begin, so a read followed by
+// a write in one can't race another process.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens or creates the database at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+	if version > sqliteVersion {
+		db.Close()
+		return nil, &ErrNewerVersion{Path: path, Version: version}
+	}
+	if err := migrateSQLite(db, version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not set up %s: %w", path, err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrateSQLite creates the schema in a new database, or upgrades one at
+// an older version, in a single transaction
+func migrateSQLite(db *sql.DB, version int) error {
+	if version == sqliteVersion {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if version == 0 {
+		if _, err := tx.Exec(sqliteSchema); err != nil {
+			return err
+		}
+	} else {
+		for v := version; v < sqliteVersion; v++ {
+			if _, err := tx.Exec(sqliteMigrations[v-1]); err != nil {
+				return fmt.Errorf("migrating from version %d: %w", v, err)
+			}
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteVersion)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddSession adds or updates a session
+func (s *SQLiteStore) AddSession(sess SessionState) error {
+	_, err := s.db.Exec(`INSERT INTO sessions (`+sessionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (worktree) DO UPDATE SET
+			name = excluded.name, repo_path = excluded.repo_path, repo_name = excluded.repo_name,
+			branch = excluded.branch, base_branch = excluded.base_branch, base_commit = excluded.base_commit,
+			parent = excluded.parent, created_at = excluded.created_at, last_access = excluded.last_access,
+			archived = excluded.archived, archive_ref = excluded.archive_ref, transcripts = excluded.transcripts,
+			port_base = excluded.port_base, port_count = excluded.port_count`,
+		sess.Name, sess.RepoPath, sess.RepoName, sess.WorkTree, sess.Branch, sess.BaseBranch,
+		sess.BaseCommit, sess.Parent, formatTime(sess.CreatedAt), formatTime(sess.LastAccess),
+		sess.Archived, sess.ArchiveRef, sess.Transcripts, sess.PortBase, sess.PortCount)
+	return err
+}
+
+// RemoveSession removes a session
+func (s *SQLiteStore) RemoveSession(worktreePath string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE worktree = ?`, worktreePath)
+	return err
+}
+
+// GetSession returns a session by worktree path
+func (s *SQLiteStore) GetSession(worktreePath string) *SessionState {
+	return s.first(`WHERE worktree = ?`, worktreePath)
+}
+
+// GetSessionByName returns a session by name and repo
+func (s *SQLiteStore) GetSessionByName(name, repoPath string) *SessionState {
+	return s.first(`WHERE name = ? AND repo_path = ?`, name, repoPath)
+}
+
+// GetAllSessions returns all tracked sessions
+func (s *SQLiteStore) GetAllSessions() []SessionState {
+	sessions, _ := s.query(``)
+	return sessions
+}
+
+// GetSessionsForRepo returns sessions for a specific repo
+func (s *SQLiteStore) GetSessionsForRepo(repoPath string) []SessionState {
+	sessions, _ := s.query(`WHERE repo_path = ?`, repoPath)
+	return sessions
+}
+
+// UpdateLastAccess updates the last access time for a session
+func (s *SQLiteStore) UpdateLastAccess(worktreePath string) error {
+	_, err := s.db.Exec(`UPDATE sessions SET last_access = ? WHERE worktree = ?`, formatTime(time.Now()), worktreePath)
+	return err
+}
+
+// Cleanup removes sessions whose worktrees no longer exist
+func (s *SQLiteStore) Cleanup() ([]SessionState, error) {
+	sessions, err := s.query(``)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []SessionState
+	for _, sess := range sessions {
+		if _, err := os.Stat(sess.WorkTree); os.IsNotExist(err) && !sess.Archived {
+			if err := s.RemoveSession(sess.WorkTree); err != nil {
+				return removed, err
+			}
+			removed = append(removed, sess)
+		}
+	}
+	return removed, nil
+}
+
+// RecordEvent appends an event to the history
+func (s *SQLiteStore) RecordEvent(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT INTO events (time, type, session, repo_path, repo_name, branch, detail, commit_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		formatTime(ev.Time), ev.Type, ev.Session, ev.RepoPath, ev.RepoName, ev.Branch, ev.Detail, ev.Commit)
+	return err
+}
+
+// Events returns recorded events matching filter, oldest first
+func (s *SQLiteStore) Events(filter EventFilter) ([]Event, error) {
+	query := `SELECT time, type, session, repo_path, repo_name, branch, detail, commit_id FROM events WHERE 1 = 1`
+	var args []interface{}
+	if filter.RepoPath != "" {
+		query += ` AND repo_path = ?`
+		args = append(args, filter.RepoPath)
+	}
+	if filter.RepoName != "" {
+		query += ` AND repo_name = ?`
+		args = append(args, filter.RepoName)
+	}
+	if filter.Session != "" {
+		query += ` AND session = ?`
+		args = append(args, filter.Session)
+	}
+	if !filter.Since.IsZero() {
+		query += ` AND time >= ?`
+		args = append(args, formatTime(filter.Since))
+	}
+	query += ` ORDER BY time, id`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var ev Event
+		var t string
+		if err := rows.Scan(&t, &ev.Type, &ev.Session, &ev.RepoPath, &ev.RepoName, &ev.Branch, &ev.Detail, &ev.Commit); err != nil {
+			return nil, err
+		}
+		ev.Time = parseTime(t)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) first(where string, args ...interface{}) *SessionState {
+	sessions, err := s.query(where, args...)
+	if err != nil || len(sessions) == 0 {
+		return nil
+	}
+	return &sessions[0]
+}
+
+func (s *SQLiteStore) query(where string, args ...interface{}) ([]SessionState, error) {
+	rows, err := s.db.Query(`SELECT `+sessionColumns+` FROM sessions `+where+` ORDER BY created_at, rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []SessionState
+	for rows.Next() {
+		var sess SessionState
+		var created, accessed string
+		if err := rows.Scan(&sess.Name, &sess.RepoPath, &sess.RepoName, &sess.WorkTree, &sess.Branch,
+			&sess.BaseBranch, &sess.BaseCommit, &sess.Parent, &created, &accessed,
+			&sess.Archived, &sess.ArchiveRef, &sess.Transcripts, &sess.PortBase, &sess.PortCount); err != nil {
+			return nil, err
+		}
+		sess.CreatedAt = parseTime(created)
+		sess.LastAccess = parseTime(accessed)
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// Times are stored as fixed-width UTC text so they sort and compare
+// correctly in SQL
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(timeLayout, s)
+	return t
+}
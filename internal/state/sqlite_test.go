@@ -0,0 +1,169 @@
+package state
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	created := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	a := SessionState{Name: "a", RepoPath: "/src/app", RepoName: "app", WorkTree: "/wt/a", Branch: "ccs/a", BaseBranch: "main", CreatedAt: created}
+	b := SessionState{Name: "b", RepoPath: "/src/site", RepoName: "site", WorkTree: "/wt/b", Branch: "ccs/b", BaseBranch: "ccs/a", Parent: "a", CreatedAt: created.Add(time.Hour)}
+	for _, sess := range []SessionState{a, b} {
+		if err := s.AddSession(sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Adding the same worktree again updates it
+	a.BaseCommit = "abc123"
+	if err := s.AddSession(a); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.GetAllSessions(); len(got) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(got))
+	}
+	got := s.GetSessionByName("a", "/src/app")
+	if got == nil || got.BaseCommit != "abc123" || !got.CreatedAt.Equal(created) {
+		t.Errorf("unexpected session %+v", got)
+	}
+	if got := s.GetSession("/wt/b"); got == nil || got.Parent != "a" {
+		t.Errorf("unexpected session %+v", got)
+	}
+
+	if err := s.RemoveSession("/wt/a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetSessionsForRepo("/src/app"); len(got) != 0 {
+		t.Errorf("expected a to be removed, got %+v", got)
+	}
+}
+
+func TestSQLiteStoreEvents(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	day := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Time: day, Type: EventCreated, Session: "a", RepoPath: "/src/app"},
+		{Time: day.Add(24 * time.Hour), Type: EventCreated, Session: "b", RepoPath: "/src/site"},
+		{Time: day.Add(48 * time.Hour), Type: EventFinished, Session: "a", RepoPath: "/src/app", Detail: "squash"},
+	}
+	for _, ev := range events {
+		if err := s.RecordEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   int
+	}{
+		{"all", EventFilter{}, 3},
+		{"repo", EventFilter{RepoPath: "/src/app"}, 2},
+		{"session", EventFilter{RepoPath: "/src/app", Session: "a"}, 2},
+		{"since", EventFilter{Since: day.Add(time.Hour)}, 2},
+		{"repo since", EventFilter{RepoPath: "/src/app", Since: day.Add(time.Hour)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Events(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("expected %d events, got %d", tt.want, len(got))
+			}
+			for _, ev := range got {
+				if !tt.filter.Match(ev) {
+					t.Errorf("event %+v does not match filter", ev)
+				}
+			}
+		})
+	}
+
+	got, _ := s.Events(EventFilter{Since: day.Add(time.Hour), RepoPath: "/src/app"})
+	if len(got) == 1 && (got[0].Detail != "squash" || !got[0].Time.Equal(day.Add(48*time.Hour))) {
+		t.Errorf("unexpected event %+v", got[0])
+	}
+}
+
+func TestSQLiteStoreMigratesVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	// The version 1 events table had no commit column, and sessions had no
+	// archive or port columns
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := sqliteSchema
+	for _, columns := range []string{
+		",\n\tcommit_id TEXT NOT NULL DEFAULT ''",
+		",\n\tport_base   INTEGER NOT NULL DEFAULT 0,\n\tport_count  INTEGER NOT NULL DEFAULT 0",
+		",\n\tarchived    INTEGER NOT NULL DEFAULT 0,\n\tarchive_ref TEXT NOT NULL DEFAULT '',\n\ttranscripts TEXT NOT NULL DEFAULT ''",
+	} {
+		schema := strings.Replace(v1, columns, "", 1)
+		if schema == v1 {
+			t.Fatal("could not derive version 1 schema")
+		}
+		v1 = schema
+	}
+	for _, stmt := range []string{
+		v1,
+		`INSERT INTO events (time, type, session, repo_path, repo_name) VALUES ('2025-06-01T09:00:00.000000000Z', 'created', 'a', '/src/app', 'app')`,
+		`PRAGMA user_version = 1`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.RecordEvent(Event{Type: EventFinished, Session: "a", RepoPath: "/src/app", Commit: "abc123"}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := s.Events(EventFilter{Session: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != EventCreated || events[1].Commit != "abc123" {
+		t.Errorf("unexpected events after migration: %+v", events)
+	}
+
+	archived := SessionState{Name: "a", RepoPath: "/src/app", WorkTree: "/wt/a", Archived: true, ArchiveRef: "refs/ccs/archive/a"}
+	if err := s.AddSession(archived); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetSession("/wt/a"); got == nil || !got.Archived || got.ArchiveRef != archived.ArchiveRef {
+		t.Errorf("archive columns not migrated: %+v", got)
+	}
+
+	ported := SessionState{Name: "b", RepoPath: "/src/app", WorkTree: "/wt/b", PortBase: 3100, PortCount: 10}
+	if err := s.AddSession(ported); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetSession("/wt/b"); got == nil || got.PortBase != 3100 || got.PortCount != 10 {
+		t.Errorf("port columns not migrated: %+v", got)
+	}
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
@@ -16,8 +17,20 @@ type SessionState struct {
 	WorkTree   string    `json:"worktree"`
 	Branch     string    `json:"branch"`
 	BaseBranch string    `json:"base_branch"`
+	BaseCommit string    `json:"base_commit,omitempty"`
+	Parent     string    `json:"parent,omitempty"` // Session this one is stacked on
 	CreatedAt  time.Time `json:"created_at"`
 	LastAccess time.Time `json:"last_access"`
+
+	// Archived sessions have no worktree or branch; the branch is kept at
+	// ArchiveRef and the Claude transcripts in Transcripts
+	Archived    bool   `json:"archived,omitempty"`
+	ArchiveRef  string `json:"archive_ref,omitempty"`
+	Transcripts string `json:"transcripts,omitempty"`
+
+	// Ports PortBase to PortBase+PortCount-1 are reserved for the session
+	PortBase  int `json:"port_base,omitempty"`
+	PortCount int `json:"port_count,omitempty"`
 }
 
 // GlobalState represents all tracked sessions across repos
@@ -26,26 +39,23 @@ type GlobalState struct {
 	Version  int            `json:"version"`
 }
 
-// Manager handles global state persistence
+// Manager handles global state persistence. Every ccs invocation is its own
+// process, so changes are made under an exclusive lock on the state file:
+// the file is reloaded, modified and atomically replaced, keeping the
+// previous version as a backup. Session history is appended to
+// events.jsonl alongside it.
 type Manager struct {
 	path  string
 	state GlobalState
 	mu    sync.RWMutex
+
+	diskVersion int // Schema version of the file as last read
 }
 
-// NewManager creates a new state manager
+// NewManager creates a new state manager backed by state.json
 func NewManager() (*Manager, error) {
-	home := os.Getenv("HOME")
-	if home == "" {
-		var err error
-		home, err = os.UserHomeDir()
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	stateDir := filepath.Join(home, ".config", "ccs")
-	if err := os.MkdirAll(stateDir, 0755); err != nil {
+	stateDir, err := Dir()
+	if err != nil {
 		return nil, err
 	}
 
@@ -53,7 +63,7 @@ func NewManager() (*Manager, error) {
 		path: filepath.Join(stateDir, "state.json"),
 		state: GlobalState{
 			Sessions: []SessionState{},
-			Version:  1,
+			Version:  CurrentVersion,
 		},
 	}
 
@@ -70,12 +80,8 @@ func (m *Manager) Load() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	data, err := os.ReadFile(m.path)
-	if err != nil {
-		return err
-	}
-
-	return json.Unmarshal(data, &m.state)
+	_, err := m.loadUnlocked()
+	return err
 }
 
 // Save writes state to disk
@@ -83,49 +89,48 @@ func (m *Manager) Save() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	data, err := json.MarshalIndent(m.state, "", "  ")
+	unlock, err := lockFile(m.path + ".lock")
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	return os.WriteFile(m.path, data, 0644)
+	previous, err := os.ReadFile(m.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if _, version, err := decodeState(previous); err == nil && version > CurrentVersion {
+		return &ErrNewerVersion{Path: m.path, Version: version}
+	}
+	return m.writeUnlocked(previous)
 }
 
 // AddSession adds or updates a session in the global state
 func (m *Manager) AddSession(sess SessionState) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Update if exists, otherwise add
-	found := false
-	for i, s := range m.state.Sessions {
-		if s.WorkTree == sess.WorkTree {
-			m.state.Sessions[i] = sess
-			found = true
-			break
+	return m.update(func(st *GlobalState) error {
+		// Update if exists, otherwise add
+		for i, s := range st.Sessions {
+			if s.WorkTree == sess.WorkTree {
+				st.Sessions[i] = sess
+				return nil
+			}
 		}
-	}
-
-	if !found {
-		m.state.Sessions = append(m.state.Sessions, sess)
-	}
-
-	return m.saveUnlocked()
+		st.Sessions = append(st.Sessions, sess)
+		return nil
+	})
 }
 
 // RemoveSession removes a session from the global state
 func (m *Manager) RemoveSession(worktreePath string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	for i, s := range m.state.Sessions {
-		if s.WorkTree == worktreePath {
-			m.state.Sessions = append(m.state.Sessions[:i], m.state.Sessions[i+1:]...)
-			break
+	return m.update(func(st *GlobalState) error {
+		for i, s := range st.Sessions {
+			if s.WorkTree == worktreePath {
+				st.Sessions = append(st.Sessions[:i], st.Sessions[i+1:]...)
+				break
+			}
 		}
-	}
-
-	return m.saveUnlocked()
+		return nil
+	})
 }
 
 // GetSession returns a session by worktree path
@@ -180,47 +185,160 @@ func (m *Manager) GetSessionsForRepo(repoPath string) []SessionState {
 
 // UpdateLastAccess updates the last access time for a session
 func (m *Manager) UpdateLastAccess(worktreePath string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.update(func(st *GlobalState) error {
+		for i, s := range st.Sessions {
+			if s.WorkTree == worktreePath {
+				st.Sessions[i].LastAccess = time.Now()
+				return nil
+			}
+		}
+		return nil
+	})
+}
 
-	for i, s := range m.state.Sessions {
-		if s.WorkTree == worktreePath {
-			m.state.Sessions[i].LastAccess = time.Now()
-			return m.saveUnlocked()
+// Cleanup removes sessions whose worktrees no longer exist
+func (m *Manager) Cleanup() ([]SessionState, error) {
+	var removed []SessionState
+	err := m.update(func(st *GlobalState) error {
+		removed = nil
+		remaining := []SessionState{}
+
+		for _, s := range st.Sessions {
+			if _, err := os.Stat(s.WorkTree); os.IsNotExist(err) && !s.Archived {
+				removed = append(removed, s)
+			} else {
+				remaining = append(remaining, s)
+			}
 		}
+
+		st.Sessions = remaining
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return removed, nil
+}
+
+// RecordEvent appends an event to events.jsonl
+func (m *Manager) RecordEvent(ev Event) error {
+	return appendEvent(m.eventsPath(), ev)
+}
+
+// Events returns the events in events.jsonl that match filter, oldest first
+func (m *Manager) Events(filter EventFilter) ([]Event, error) {
+	return readEvents(m.eventsPath(), filter)
+}
+
+// Close does nothing; every change is written immediately
+func (m *Manager) Close() error {
 	return nil
 }
 
-// Cleanup removes sessions whose worktrees no longer exist
-func (m *Manager) Cleanup() ([]SessionState, error) {
+// update applies fn to the state while holding the lock on the state file.
+// The file is reloaded first so changes made by other processes since this
+// one loaded it are not lost.
+func (m *Manager) update(fn func(*GlobalState) error) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	var removed []SessionState
-	var remaining []SessionState
+	unlock, err := lockFile(m.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	for _, s := range m.state.Sessions {
-		if _, err := os.Stat(s.WorkTree); os.IsNotExist(err) {
-			removed = append(removed, s)
-		} else {
-			remaining = append(remaining, s)
-		}
+	previous, err := m.loadUnlocked()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if m.diskVersion > CurrentVersion {
+		return &ErrNewerVersion{Path: m.path, Version: m.diskVersion}
 	}
 
-	m.state.Sessions = remaining
-	if err := m.saveUnlocked(); err != nil {
+	if err := fn(&m.state); err != nil {
+		return err
+	}
+
+	return m.writeUnlocked(previous)
+}
+
+// loadUnlocked reads and migrates the state file, falling back to the backup
+// if the file is corrupt, and returns the raw contents that were read
+func (m *Manager) loadUnlocked() ([]byte, error) {
+	data, err := os.ReadFile(m.path)
+	if err != nil {
 		return nil, err
 	}
 
-	return removed, nil
+	st, version, err := decodeState(data)
+	if err != nil {
+		backup, berr := os.ReadFile(m.backupPath())
+		if berr != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", m.path, err)
+		}
+		var derr error
+		if st, version, derr = decodeState(backup); derr != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", m.path, err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s is corrupt (%v), using backup %s\n", m.path, err, m.backupPath())
+		data = backup
+	}
+
+	m.state = st
+	m.diskVersion = version
+	return data, nil
 }
 
-func (m *Manager) saveUnlocked() error {
+// writeUnlocked atomically replaces the state file, first saving previous
+// (the contents being replaced) as the backup
+func (m *Manager) writeUnlocked(previous []byte) error {
+	m.state.Version = CurrentVersion
 	data, err := json.MarshalIndent(m.state, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(m.path, data, 0644)
+	if len(previous) > 0 {
+		if err := writeFileAtomic(m.backupPath(), previous); err != nil {
+			return fmt.Errorf("could not back up state: %w", err)
+		}
+	}
+
+	return writeFileAtomic(m.path, data)
+}
+
+func (m *Manager) backupPath() string {
+	return m.path + ".bak"
+}
+
+func (m *Manager) eventsPath() string {
+	return filepath.Join(filepath.Dir(m.path), "events.jsonl")
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
 }
@@ -0,0 +1,157 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentAddSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Separate managers stand in for separate ccs processes, each with its
+	// own stale in-memory copy of the state
+	const n = 20
+	managers := make([]*Manager, n)
+	for i := range managers {
+		m, err := NewManager()
+		if err != nil {
+			t.Fatal(err)
+		}
+		managers[i] = m
+	}
+
+	var wg sync.WaitGroup
+	for i, m := range managers {
+		wg.Add(1)
+		go func(i int, m *Manager) {
+			defer wg.Done()
+			name := fmt.Sprintf("s%d", i)
+			if err := m.AddSession(SessionState{Name: name, WorkTree: "/wt/" + name}); err != nil {
+				t.Error(err)
+			}
+		}(i, m)
+	}
+	wg.Wait()
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(m.GetAllSessions()); got != n {
+		t.Errorf("expected %d sessions, got %d", n, got)
+	}
+}
+
+func TestLoadFallsBackToBackup(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddSession(SessionState{Name: "a", WorkTree: "/wt/a"})
+	m.AddSession(SessionState{Name: "b", WorkTree: "/wt/b"})
+
+	// A crash mid-write used to leave a truncated file
+	if err := os.WriteFile(m.path, []byte(`{"sessions": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = NewManager()
+	if err != nil {
+		t.Fatalf("expected recovery from backup, got %v", err)
+	}
+	sessions := m.GetAllSessions()
+	if len(sessions) != 1 || sessions[0].Name != "a" {
+		t.Errorf("expected the backed up state with session a, got %+v", sessions)
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(m.path), "state.json.bak")); err != nil {
+		t.Errorf("expected a backup file: %v", err)
+	}
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestDecodeStateGolden loads state files written by each schema version and
+// compares the migrated result with testdata/<name>.golden
+func TestDecodeStateGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, _, err := decodeState(data)
+			if err != nil {
+				t.Fatalf("decodeState: %v", err)
+			}
+			got, err := json.MarshalIndent(st, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, append(got, '\n'), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got)+"\n" != string(want) {
+				t.Errorf("migrated state differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestRefuseToOverwriteNewerVersion(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := os.ReadFile(filepath.Join("testdata", "v99.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(m.path, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = NewManager()
+	if err != nil {
+		t.Fatalf("expected a newer file to be readable, got %v", err)
+	}
+	if got := len(m.GetAllSessions()); got != 1 {
+		t.Errorf("expected 1 session, got %d", got)
+	}
+
+	err = m.AddSession(SessionState{Name: "x", WorkTree: "/wt/x"})
+	var newerErr *ErrNewerVersion
+	if !errors.As(err, &newerErr) || newerErr.Version != 99 {
+		t.Fatalf("expected ErrNewerVersion, got %v", err)
+	}
+
+	onDisk, _ := os.ReadFile(m.path)
+	if string(onDisk) != string(newer) {
+		t.Error("state file was modified")
+	}
+}
@@ -0,0 +1,131 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store persists sessions across repositories and, where supported, a
+// history of what happened to them
+type Store interface {
+	AddSession(sess SessionState) error
+	RemoveSession(worktreePath string) error
+	GetSession(worktreePath string) *SessionState
+	GetSessionByName(name, repoPath string) *SessionState
+	GetAllSessions() []SessionState
+	GetSessionsForRepo(repoPath string) []SessionState
+	UpdateLastAccess(worktreePath string) error
+	Cleanup() ([]SessionState, error)
+
+	// ReservePorts returns a session's block of ports, atomically reserving
+	// count ports from start if it has none
+	ReservePorts(worktreePath string, start, count int, free func(port int) bool) (base, n int, err error)
+
+	// RecordEvent appends an event to the session history
+	RecordEvent(ev Event) error
+	// Events returns recorded events matching filter, oldest first
+	Events(filter EventFilter) ([]Event, error)
+
+	Close() error
+}
+
+// Store backends
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Session event types
+const (
+	EventCreated  = "created"
+	EventSwitched = "switched"
+	EventPaused   = "paused"
+	EventResumed  = "resumed"
+	EventSynced   = "synced"
+	EventRenamed  = "renamed"
+	EventArchived = "archived"
+	EventRestored = "restored"
+	EventFinished = "finished"
+	EventDeleted  = "deleted"
+)
+
+// Event is something that happened to a session
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Session  string    `json:"session"`
+	RepoPath string    `json:"repo_path"`
+	RepoName string    `json:"repo_name"`
+	Branch   string    `json:"branch,omitempty"`
+	Detail   string    `json:"detail,omitempty"` // e.g. the finish strategy
+	Commit   string    `json:"commit,omitempty"` // Commit the operation produced
+}
+
+// EventFilter selects events; zero fields match everything
+type EventFilter struct {
+	RepoPath string
+	RepoName string
+	Session  string
+	Since    time.Time
+}
+
+// Match reports whether ev passes the filter
+func (f EventFilter) Match(ev Event) bool {
+	if f.RepoPath != "" && ev.RepoPath != f.RepoPath {
+		return false
+	}
+	if f.RepoName != "" && ev.RepoName != f.RepoName {
+		return false
+	}
+	if f.Session != "" && ev.Session != f.Session {
+		return false
+	}
+	if !f.Since.IsZero() && ev.Time.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Open opens the store for a backend, defaulting to JSON
+func Open(backend string) (Store, error) {
+	switch backend {
+	case "", BackendJSON:
+		m, err := NewManager()
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	case BackendSQLite:
+		dir, err := Dir()
+		if err != nil {
+			return nil, err
+		}
+		s, err := NewSQLiteStore(filepath.Join(dir, "state.db"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown state backend %q (supported: json, sqlite)", backend)
+	}
+}
+
+// Dir returns the directory state is kept in, creating it if needed
+func Dir() (string, error) {
+	home := os.Getenv("HOME")
+	if home == "" {
+		var err error
+		home, err = os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	dir := filepath.Join(home, ".config", "ccs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
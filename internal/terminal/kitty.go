@@ -38,11 +38,14 @@ func (k *KittyTerminal) tabName(name string) string {
 	return k.tabPrefix + name
 }
 
-func (k *KittyTerminal) CreateWindow(name, path, startCmd string) error {
+func (k *KittyTerminal) CreateWindow(name, path, startCmd string, env []string) error {
 	tabName := k.tabName(name)
 
 	// Create tab with default shell - capture the window ID from output
 	args := []string{"@", "launch", "--type=tab", "--tab-title", tabName, "--cwd", path}
+	for _, kv := range env {
+		args = append(args, "--env", kv)
+	}
 	out, err := exec.Command("kitty", args...).Output()
 	if err != nil {
 		return err
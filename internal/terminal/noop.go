@@ -7,7 +7,7 @@ func (n *NoopTerminal) Name() string {
 	return "none"
 }
 
-func (n *NoopTerminal) CreateWindow(name, path, startCmd string) error {
+func (n *NoopTerminal) CreateWindow(name, path, startCmd string, env []string) error {
 	return nil
 }
 
@@ -9,7 +9,9 @@ import (
 // Terminal is the interface for terminal operations
 type Terminal interface {
 	Name() string
-	CreateWindow(name, path, startCmd string) error
+	// CreateWindow opens a window in path with env ("KEY=value") added to
+	// its environment, running startCmd in its shell if set
+	CreateWindow(name, path, startCmd string, env []string) error
 	SwitchWindow(name string) error
 	CloseWindow(name string) error
 	WindowExists(name string) bool
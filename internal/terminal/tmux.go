@@ -28,11 +28,15 @@ func (t *TmuxTerminal) windowName(name string) string {
 	return t.windowPrefix + name
 }
 
-func (t *TmuxTerminal) CreateWindow(name, path, startCmd string) error {
+func (t *TmuxTerminal) CreateWindow(name, path, startCmd string, env []string) error {
 	windowName := t.windowName(name)
 
 	// Create window with default shell (inherits from tmux/environment)
-	if err := exec.Command("tmux", "new-window", "-n", windowName, "-c", path).Run(); err != nil {
+	args := []string{"new-window", "-n", windowName, "-c", path}
+	for _, kv := range env {
+		args = append(args, "-e", kv)
+	}
+	if err := exec.Command("tmux", args...).Run(); err != nil {
 		return err
 	}
 